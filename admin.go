@@ -0,0 +1,428 @@
+package orchestrator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/heimdalr/dag"
+)
+
+// DefaultAdminRunsLimit is the number of Runs returned by an AdminHandler
+// where the request doesn't specify a limit
+const DefaultAdminRunsLimit = 100
+
+// AdminHandler is an http.Handler which exposes an Orchestrator's DAG, and
+// the ability to control it, as JSON. It serves:
+//
+//	GET    /inputs                   every Input, with its live state (see Inputs)
+//	GET    /inputs/{id}              a single Input
+//	POST   /inputs/{id}/pause        pause an Input (see PauseInput)
+//	POST   /inputs/{id}/resume       resume an Input (see ResumeInput)
+//	GET    /processes                every Process, with its live state (see Processes)
+//	GET    /processes/{id}           a single Process
+//	POST   /processes/{id}/trigger   run a Process with the Event in the request body (see Trigger)
+//	GET    /links                    every link (see Links)
+//	POST   /links                    add a link, from a LinkInfo in the request body
+//	DELETE /links/{parent}/{child}   remove a link (see RemoveLink)
+//	GET    /runs                     recent Runs (see Runs)
+//
+// /runs accepts the query parameters process, input, event_id, status (which
+// may be repeated), since and until (as RFC3339 timestamps), and limit, which
+// map onto the fields of a RunQuery.
+//
+// Paths are relative to the root of the handler, and so mounting somewhere
+// else needs http.StripPrefix, such as:
+//
+//	mux.Handle("/admin/", http.StripPrefix("/admin", orchestrator.NewAdminHandler(d)))
+//
+// AdminHandler does no authentication of its own, and so should only be
+// served somewhere trusted, or wrapped in something which does
+type AdminHandler struct {
+	orchestrator *Orchestrator
+
+	// MaxBodySize is the largest request body, in bytes, accepted
+	MaxBodySize int64
+}
+
+// NewAdminHandler returns an AdminHandler for d
+func NewAdminHandler(d *Orchestrator) *AdminHandler {
+	return &AdminHandler{
+		orchestrator: d,
+		MaxBodySize:  DefaultWebhookMaxBodySize,
+	}
+}
+
+// ServeHTTP implements the http.Handler interface, routing requests to
+// the relevant endpoint
+func (a *AdminHandler) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	path, err := splitPath(r.URL)
+	if err != nil {
+		writeError(rw, http.StatusBadRequest, err)
+
+		return
+	}
+
+	switch {
+	case match(path, "inputs"):
+		a.route(rw, r, http.MethodGet, a.inputs)
+
+	case match(path, "inputs", "*"):
+		a.route(rw, r, http.MethodGet, func(rw http.ResponseWriter, r *http.Request) {
+			a.input(rw, r, path[1])
+		})
+
+	case match(path, "inputs", "*", "pause"):
+		a.route(rw, r, http.MethodPost, func(rw http.ResponseWriter, r *http.Request) {
+			a.pause(rw, r, path[1], true)
+		})
+
+	case match(path, "inputs", "*", "resume"):
+		a.route(rw, r, http.MethodPost, func(rw http.ResponseWriter, r *http.Request) {
+			a.pause(rw, r, path[1], false)
+		})
+
+	case match(path, "processes"):
+		a.route(rw, r, http.MethodGet, a.processes)
+
+	case match(path, "processes", "*"):
+		a.route(rw, r, http.MethodGet, func(rw http.ResponseWriter, r *http.Request) {
+			a.process(rw, r, path[1])
+		})
+
+	case match(path, "processes", "*", "trigger"):
+		a.route(rw, r, http.MethodPost, func(rw http.ResponseWriter, r *http.Request) {
+			a.trigger(rw, r, path[1])
+		})
+
+	case match(path, "links"):
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(rw, http.StatusOK, a.orchestrator.Links())
+
+		case http.MethodPost:
+			a.addLink(rw, r)
+
+		default:
+			methodNotAllowed(rw, http.MethodGet, http.MethodPost)
+		}
+
+	case match(path, "links", "*", "*"):
+		a.route(rw, r, http.MethodDelete, func(rw http.ResponseWriter, r *http.Request) {
+			a.removeLink(rw, r, path[1], path[2])
+		})
+
+	case match(path, "runs"):
+		a.route(rw, r, http.MethodGet, a.runs)
+
+	default:
+		writeError(rw, http.StatusNotFound, errors.New(http.StatusText(http.StatusNotFound)))
+	}
+}
+
+// route calls h where r uses method, responding with 405 Method Not
+// Allowed otherwise
+func (a *AdminHandler) route(rw http.ResponseWriter, r *http.Request, method string, h http.HandlerFunc) {
+	if r.Method != method {
+		methodNotAllowed(rw, method)
+
+		return
+	}
+
+	h(rw, r)
+}
+
+func (a *AdminHandler) inputs(rw http.ResponseWriter, _ *http.Request) {
+	writeJSON(rw, http.StatusOK, a.orchestrator.Inputs())
+}
+
+func (a *AdminHandler) input(rw http.ResponseWriter, _ *http.Request, id string) {
+	for _, i := range a.orchestrator.Inputs() {
+		if i.ID == id {
+			writeJSON(rw, http.StatusOK, i)
+
+			return
+		}
+	}
+
+	writeError(rw, http.StatusNotFound, UnknownInputError{input: id})
+}
+
+func (a *AdminHandler) pause(rw http.ResponseWriter, r *http.Request, id string, paused bool) {
+	err := a.orchestrator.setPaused(id, paused)
+	if err != nil {
+		writeError(rw, http.StatusNotFound, err)
+
+		return
+	}
+
+	a.input(rw, r, id)
+}
+
+func (a *AdminHandler) processes(rw http.ResponseWriter, _ *http.Request) {
+	writeJSON(rw, http.StatusOK, a.orchestrator.Processes())
+}
+
+func (a *AdminHandler) process(rw http.ResponseWriter, _ *http.Request, id string) {
+	for _, p := range a.orchestrator.Processes() {
+		if p.ID == id {
+			writeJSON(rw, http.StatusOK, p)
+
+			return
+		}
+	}
+
+	writeError(rw, http.StatusNotFound, UnknownProcessIDError{process: id})
+}
+
+func (a *AdminHandler) trigger(rw http.ResponseWriter, r *http.Request, id string) {
+	var e Event
+
+	if !a.decode(rw, r, &e) {
+		return
+	}
+
+	err := a.orchestrator.Trigger(id, e)
+	if err != nil {
+		writeError(rw, errorStatus(err), err)
+
+		return
+	}
+
+	writeJSON(rw, http.StatusAccepted, e)
+}
+
+func (a *AdminHandler) addLink(rw http.ResponseWriter, r *http.Request) {
+	var l LinkInfo
+
+	if !a.decode(rw, r, &l) {
+		return
+	}
+
+	// Check the same things AddLink and AddProcessLink enforce through
+	// their types, and FromConfig through Validate
+	_, parentIsInput := a.orchestrator.inputs.Load(l.Parent)
+	_, parentIsProcess := a.orchestrator.processes.Load(l.Parent)
+	_, childIsInput := a.orchestrator.inputs.Load(l.Child)
+	_, childIsProcess := a.orchestrator.processes.Load(l.Child)
+
+	switch {
+	case !parentIsInput && !parentIsProcess:
+		writeError(rw, http.StatusBadRequest, fmt.Errorf("unknown input or process %q", l.Parent))
+
+		return
+
+	case childIsInput:
+		writeError(rw, http.StatusBadRequest, fmt.Errorf("%q is an input, and so cannot be linked to", l.Child))
+
+		return
+
+	case !childIsProcess:
+		writeError(rw, http.StatusBadRequest, fmt.Errorf("unknown process %q", l.Child))
+
+		return
+	}
+
+	err := a.orchestrator.addLink(l.Parent, l.Child, []LinkOption{WithLinkOperations(l.Operations...)})
+	if err != nil {
+		writeError(rw, errorStatus(err), err)
+
+		return
+	}
+
+	l.Filtered = 0
+	writeJSON(rw, http.StatusCreated, l)
+}
+
+func (a *AdminHandler) removeLink(rw http.ResponseWriter, _ *http.Request, parent, child string) {
+	err := a.orchestrator.RemoveLink(parent, child)
+	if err != nil {
+		writeError(rw, errorStatus(err), err)
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+func (a *AdminHandler) runs(rw http.ResponseWriter, r *http.Request) {
+	q, err := parseRunQuery(r.URL.Query())
+	if err != nil {
+		writeError(rw, http.StatusBadRequest, err)
+
+		return
+	}
+
+	runs, err := a.orchestrator.Runs(r.Context(), q)
+	if err != nil {
+		writeError(rw, errorStatus(err), err)
+
+		return
+	}
+
+	if runs == nil {
+		runs = make([]Run, 0)
+	}
+
+	writeJSON(rw, http.StatusOK, runs)
+}
+
+// decode reads a json request body into v, responding with an error and
+// returning false where it can't
+func (a *AdminHandler) decode(rw http.ResponseWriter, r *http.Request, v any) bool {
+	body, err := readBody(rw, r, a.MaxBodySize)
+	if err != nil {
+		var mbe *http.MaxBytesError
+		if errors.As(err, &mbe) {
+			writeError(rw, http.StatusRequestEntityTooLarge, err)
+
+			return false
+		}
+
+		writeError(rw, http.StatusBadRequest, err)
+
+		return false
+	}
+
+	err = json.Unmarshal(body, v)
+	if err != nil {
+		writeError(rw, http.StatusBadRequest, err)
+
+		return false
+	}
+
+	return true
+}
+
+// parseRunQuery turns the query parameters of a request to /runs into
+// a RunQuery
+func parseRunQuery(v url.Values) (q RunQuery, err error) {
+	q = RunQuery{
+		Process: v.Get("process"),
+		Input:   v.Get("input"),
+		EventID: v.Get("event_id"),
+		Limit:   DefaultAdminRunsLimit,
+	}
+
+	for _, s := range v["status"] {
+		var status ProcessExitStatus
+
+		err = status.UnmarshalText([]byte(s))
+		if err != nil {
+			return
+		}
+
+		q.Statuses = append(q.Statuses, status)
+	}
+
+	for param, t := range map[string]*time.Time{"since": &q.Since, "until": &q.Until} {
+		if !v.Has(param) {
+			continue
+		}
+
+		*t, err = time.Parse(time.RFC3339, v.Get(param))
+		if err != nil {
+			return
+		}
+	}
+
+	if v.Has("limit") {
+		q.Limit, err = strconv.Atoi(v.Get("limit"))
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// splitPath splits the path of u into its segments, unescaping each, so
+// that IDs may contain slashes when escaped
+func splitPath(u *url.URL) (path []string, err error) {
+	for _, s := range strings.Split(strings.Trim(u.EscapedPath(), "/"), "/") {
+		if s == "" {
+			continue
+		}
+
+		s, err = url.PathUnescape(s)
+		if err != nil {
+			return
+		}
+
+		path = append(path, s)
+	}
+
+	return
+}
+
+// match returns true where path matches pattern, where "*" in pattern
+// matches any single segment
+func match(path []string, pattern ...string) bool {
+	if len(path) != len(pattern) {
+		return false
+	}
+
+	for i := range pattern {
+		if pattern[i] != "*" && pattern[i] != path[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// errorStatus returns the HTTP status code best describing err
+func errorStatus(err error) int {
+	var (
+		unknownInput   UnknownInputError
+		unknownProcess UnknownProcessError
+		unknownID      UnknownProcessIDError
+		unknownLink    UnknownLinkError
+		unknownVertex  dag.IDUnknownError
+		duplicateEdge  dag.EdgeDuplicateError
+		edgeLoop       dag.EdgeLoopError
+		srcDstEqual    dag.SrcDstEqualError
+	)
+
+	switch {
+	case errors.As(err, &unknownInput), errors.As(err, &unknownProcess),
+		errors.As(err, &unknownID), errors.As(err, &unknownLink),
+		errors.As(err, &unknownVertex):
+		return http.StatusNotFound
+
+	case errors.As(err, &duplicateEdge):
+		return http.StatusConflict
+
+	case errors.Is(err, ErrNoRunStore):
+		return http.StatusNotImplemented
+
+	case errors.Is(err, ErrOrchestratorClosed):
+		return http.StatusServiceUnavailable
+
+	case errors.As(err, &edgeLoop), errors.As(err, &srcDstEqual):
+		return http.StatusBadRequest
+	}
+
+	return http.StatusInternalServerError
+}
+
+func methodNotAllowed(rw http.ResponseWriter, methods ...string) {
+	rw.Header().Set("Allow", strings.Join(methods, ", "))
+	writeError(rw, http.StatusMethodNotAllowed, errors.New(http.StatusText(http.StatusMethodNotAllowed)))
+}
+
+func writeError(rw http.ResponseWriter, status int, err error) {
+	writeJSON(rw, status, map[string]string{"error": err.Error()})
+}
+
+func writeJSON(rw http.ResponseWriter, status int, v any) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(status)
+
+	json.NewEncoder(rw).Encode(v)
+}
@@ -0,0 +1,228 @@
+package orchestrator_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dapper-data/dapper-orchestrator"
+)
+
+func setupAdminTest(t *testing.T, opts ...orchestrator.Option) (*httptest.Server, *orchestrator.Orchestrator, *recordingProcess) {
+	t.Helper()
+
+	p := newRecordingProcess("recording", orchestrator.ProcessSuccess)
+	d := setupOrchestrator(t, testDAG{
+		opts:    opts,
+		process: p,
+		inputs:  []orchestrator.Input{newSequence("sequence-input", 0)},
+	})
+
+	s := httptest.NewServer(orchestrator.NewAdminHandler(d))
+	t.Cleanup(s.Close)
+
+	return s, d, p
+}
+
+func adminRequest(t *testing.T, s *httptest.Server, method, path, body string) (int, string) {
+	t.Helper()
+
+	req, err := http.NewRequest(method, s.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := s.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return resp.StatusCode, strings.TrimSpace(string(b))
+}
+
+func TestAdminHandler(t *testing.T) {
+	s, _, _ := setupAdminTest(t)
+
+	for _, test := range []struct {
+		name         string
+		method       string
+		path         string
+		body         string
+		expectStatus int
+		expectBody   string
+	}{
+		{"list inputs", http.MethodGet, "/inputs", "", http.StatusOK, `[{"id":"sequence-input","state":"running","paused":false,"in_flight":0,"operations":null,"filtered":0}]`},
+		{"get input", http.MethodGet, "/inputs/sequence-input", "", http.StatusOK, `{"id":"sequence-input","state":"running","paused":false,"in_flight":0,"operations":null,"filtered":0}`},
+		{"get unknown input", http.MethodGet, "/inputs/nonsuch", "", http.StatusNotFound, `{"error":"input \"nonsuch\" is unknown"}`},
+		{"get unknown process", http.MethodGet, "/processes/nonsuch", "", http.StatusNotFound, `{"error":"process \"nonsuch\" is unknown"}`},
+		{"list processes", http.MethodGet, "/processes", "", http.StatusOK, `[{"id":"recording","in_flight":0,"timeout":0}]`},
+		{"get process", http.MethodGet, "/processes/recording", "", http.StatusOK, `{"id":"recording","in_flight":0,"timeout":0}`},
+		{"list links", http.MethodGet, "/links", "", http.StatusOK, `[{"parent":"sequence-input","child":"recording","operations":null,"filtered":0}]`},
+		{"add duplicate link", http.MethodPost, "/links", `{"parent":"sequence-input","child":"recording"}`, http.StatusConflict, ""},
+		{"add link to unknown process", http.MethodPost, "/links", `{"parent":"sequence-input","child":"nonsuch"}`, http.StatusBadRequest, `{"error":"unknown process \"nonsuch\""}`},
+		{"add link from unknown parent", http.MethodPost, "/links", `{"parent":"nonsuch","child":"recording"}`, http.StatusBadRequest, `{"error":"unknown input or process \"nonsuch\""}`},
+		{"add link to an input", http.MethodPost, "/links", `{"parent":"recording","child":"sequence-input"}`, http.StatusBadRequest, `{"error":"\"sequence-input\" is an input, and so cannot be linked to"}`},
+		{"add link with bad json", http.MethodPost, "/links", `{`, http.StatusBadRequest, ""},
+		{"remove unknown link", http.MethodDelete, "/links/recording/sequence-input", "", http.StatusNotFound, `{"error":"link from \"recording\" to \"sequence-input\" is unknown"}`},
+		{"trigger unknown process", http.MethodPost, "/processes/nonsuch/trigger", `{"id":"1"}`, http.StatusNotFound, ""},
+		{"runs without a run store", http.MethodGet, "/runs", "", http.StatusNotImplemented, ""},
+		{"wrong method", http.MethodPost, "/inputs", "", http.StatusMethodNotAllowed, ""},
+		{"unknown path", http.MethodGet, "/nonsuch", "", http.StatusNotFound, ""},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			status, body := adminRequest(t, s, test.method, test.path, test.body)
+			if test.expectStatus != status {
+				t.Errorf("expected %d, received %d (%s)", test.expectStatus, status, body)
+			}
+
+			if test.expectBody != "" && test.expectBody != body {
+				t.Errorf("expected %q, received %q", test.expectBody, body)
+			}
+		})
+	}
+}
+
+func TestAdminHandler_PauseResume(t *testing.T) {
+	s, d, _ := setupAdminTest(t)
+
+	for _, test := range []struct {
+		path   string
+		expect bool
+	}{
+		{"/inputs/sequence-input/pause", true},
+		{"/inputs/sequence-input/resume", false},
+	} {
+		t.Run(test.path, func(t *testing.T) {
+			status, body := adminRequest(t, s, http.MethodPost, test.path, "")
+			if status != http.StatusOK {
+				t.Fatalf("expected %d, received %d (%s)", http.StatusOK, status, body)
+			}
+
+			var i orchestrator.InputInfo
+
+			err := json.Unmarshal([]byte(body), &i)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if test.expect != i.Paused {
+				t.Errorf("expected %v, received %v", test.expect, i.Paused)
+			}
+
+			if test.expect != d.Inputs()[0].Paused {
+				t.Errorf("expected %v, received %v", test.expect, d.Inputs()[0].Paused)
+			}
+		})
+	}
+}
+
+func TestAdminHandler_Trigger(t *testing.T) {
+	s, _, p := setupAdminTest(t)
+
+	status, body := adminRequest(t, s, http.MethodPost, "/processes/recording/trigger", `{"id":"123","operation":"update","trigger":"someone"}`)
+	if status != http.StatusAccepted {
+		t.Fatalf("expected %d, received %d (%s)", http.StatusAccepted, status, body)
+	}
+
+	ev := p.next(t)
+	if ev.ID != "123" || ev.Operation != orchestrator.OperationUpdate || ev.Trigger != "someone" {
+		t.Errorf("unexpected event %#v", ev)
+	}
+}
+
+func TestAdminHandler_Links(t *testing.T) {
+	s, d, _ := setupAdminTest(t)
+
+	status, body := adminRequest(t, s, http.MethodDelete, "/links/sequence-input/recording", "")
+	if status != http.StatusNoContent {
+		t.Fatalf("expected %d, received %d (%s)", http.StatusNoContent, status, body)
+	}
+
+	if l := d.Links(); len(l) != 0 {
+		t.Errorf("expected no links, received %#v", l)
+	}
+
+	status, body = adminRequest(t, s, http.MethodPost, "/links", `{"parent":"sequence-input","child":"recording","operations":["create"]}`)
+	if status != http.StatusCreated {
+		t.Fatalf("expected %d, received %d (%s)", http.StatusCreated, status, body)
+	}
+
+	expect := []orchestrator.LinkInfo{{Parent: "sequence-input", Child: "recording", Operations: []orchestrator.Operation{orchestrator.OperationCreate}}}
+	if received := d.Links(); len(received) != 1 || received[0].Operations[0] != expect[0].Operations[0] {
+		t.Errorf("expected %#v, received %#v", expect, received)
+	}
+}
+
+func TestAdminHandler_Runs(t *testing.T) {
+	s, _, p := setupAdminTest(t, orchestrator.WithRunStore(orchestrator.NewMemoryRunStore(0)))
+
+	for _, id := range []string{"1", "2"} {
+		status, body := adminRequest(t, s, http.MethodPost, "/processes/recording/trigger", `{"id":"`+id+`"}`)
+		if status != http.StatusAccepted {
+			t.Fatalf("expected %d, received %d (%s)", http.StatusAccepted, status, body)
+		}
+
+		p.next(t)
+	}
+
+	for _, test := range []struct {
+		query        string
+		expectStatus int
+		expectIDs    []string
+	}{
+		{"", http.StatusOK, []string{"2", "1"}},
+		{"?event_id=1", http.StatusOK, []string{"1"}},
+		{"?status=success&limit=1", http.StatusOK, []string{"2"}},
+		{"?status=fail", http.StatusOK, []string{}},
+		{"?process=nonsuch", http.StatusOK, []string{}},
+		{"?status=meh", http.StatusBadRequest, nil},
+		{"?since=yesterday", http.StatusBadRequest, nil},
+		{"?limit=lots", http.StatusBadRequest, nil},
+	} {
+		t.Run(test.query, func(t *testing.T) {
+			var (
+				status int
+				body   string
+				runs   []orchestrator.Run
+			)
+
+			// Runs are saved once a Process returns, and so
+			// may not have landed just yet
+			eventually(t, "runs to be saved", func() bool {
+				status, body = adminRequest(t, s, http.MethodGet, "/runs"+test.query, "")
+				if status != http.StatusOK {
+					return true
+				}
+
+				runs = nil
+
+				err := json.Unmarshal([]byte(body), &runs)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				return len(runs) == len(test.expectIDs)
+			})
+
+			if test.expectStatus != status {
+				t.Fatalf("expected %d, received %d (%s)", test.expectStatus, status, body)
+			}
+
+			for i, r := range runs {
+				if test.expectIDs[i] != r.Event.ID {
+					t.Errorf("expected %q, received %q", test.expectIDs[i], r.Event.ID)
+				}
+			}
+		})
+	}
+}
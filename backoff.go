@@ -0,0 +1,64 @@
+package orchestrator
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// backoffLimit caps Backoffs which don't set a Max, so that delays can't
+// grow until they overflow
+const backoffLimit = time.Hour * 24
+
+// Backoff describes an exponential backoff, with optional jitter, used
+// when waiting between attempts at something
+type Backoff struct {
+	// Initial is the delay before the first retry
+	Initial time.Duration
+
+	// Max caps the delay between retries; a zero value caps it at a day
+	Max time.Duration
+
+	// Multiplier is applied to the delay after each attempt. Values
+	// below 1 are treated as 1
+	Multiplier float64
+
+	// Jitter is the fraction, between 0 and 1, of each delay which is
+	// randomised in order to stop retries from synchronising
+	Jitter float64
+}
+
+// Duration returns the delay to use before the nth retry, where n
+// starts at zero
+func (b Backoff) Duration(n int) time.Duration {
+	if b.Initial <= 0 {
+		return 0
+	}
+
+	limit := backoffLimit
+	if b.Max > 0 {
+		limit = b.Max
+	}
+
+	multiplier := math.Max(b.Multiplier, 1)
+
+	d := float64(b.Initial) * math.Pow(multiplier, float64(n))
+	if d > float64(limit) {
+		d = float64(limit)
+	}
+
+	jitter := math.Min(math.Max(b.Jitter, 0), 1)
+	if jitter > 0 {
+		d -= d * jitter * rand.Float64()
+	}
+
+	return time.Duration(d)
+}
+
+// limit returns the longest delay Duration can return for n, being the delay
+// before any jitter is applied
+func (b Backoff) limit(n int) time.Duration {
+	b.Jitter = 0
+
+	return b.Duration(n)
+}
@@ -0,0 +1,43 @@
+package orchestrator_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dapper-data/dapper-orchestrator"
+)
+
+func TestBackoff_Duration(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		b      orchestrator.Backoff
+		n      int
+		expect time.Duration
+	}{
+		{"zero value", orchestrator.Backoff{}, 5, 0},
+		{"constant", orchestrator.Backoff{Initial: time.Second}, 5, time.Second},
+		{"first attempt", orchestrator.Backoff{Initial: time.Second, Multiplier: 2}, 0, time.Second},
+		{"exponential", orchestrator.Backoff{Initial: time.Second, Multiplier: 2}, 3, time.Second * 8},
+		{"capped", orchestrator.Backoff{Initial: time.Second, Multiplier: 2, Max: time.Second * 5}, 3, time.Second * 5},
+		{"uncapped", orchestrator.Backoff{Initial: time.Second, Multiplier: 2}, 1000, time.Hour * 24},
+		{"uncapped overflow", orchestrator.Backoff{Initial: time.Second, Multiplier: 2}, 100000, time.Hour * 24},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			received := test.b.Duration(test.n)
+			if test.expect != received {
+				t.Errorf("expected %s, received %s", test.expect, received)
+			}
+		})
+	}
+}
+
+func TestBackoff_Duration_Jitter(t *testing.T) {
+	b := orchestrator.Backoff{Initial: time.Second, Jitter: 0.5}
+
+	for i := 0; i < 100; i++ {
+		received := b.Duration(0)
+		if received < time.Millisecond*500 || received > time.Second {
+			t.Fatalf("expected duration between 500ms and 1s, received %s", received)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package orchestrator
+
+import (
+	"time"
+)
+
+// Clock tells an Orchestrator the time, and is used for timestamps and for
+// waiting between retries and restarts. It exists so that tests can control
+// time (see WithClock)
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// SystemClock is the Clock used by default, which uses the time package
+type SystemClock struct{}
+
+// Now returns the current time
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// After waits for d to elapse, and then sends the current time on the
+// returned channel
+func (SystemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
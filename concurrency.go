@@ -0,0 +1,269 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// UnknownInputError returns when trying to configure an input which doesn't
+// exist
+type UnknownInputError struct {
+	input string
+}
+
+// Error returns a descriptive error message
+func (e UnknownInputError) Error() string {
+	return fmt.Sprintf("input %q is unknown", e.input)
+}
+
+// NewTestUnknownInputError can be used to return a testable error (in tests)
+func NewTestUnknownInputError(input string) UnknownInputError {
+	return UnknownInputError{
+		input: input,
+	}
+}
+
+// UnknownProcessIDError returns when trying to configure or look up a
+// process which doesn't exist
+type UnknownProcessIDError struct {
+	process string
+}
+
+// Error returns a descriptive error message
+func (e UnknownProcessIDError) Error() string {
+	return fmt.Sprintf("process %q is unknown", e.process)
+}
+
+// NewTestUnknownProcessIDError can be used to return a testable error (in tests)
+func NewTestUnknownProcessIDError(process string) UnknownProcessIDError {
+	return UnknownProcessIDError{
+		process: process,
+	}
+}
+
+// SetConcurrency sets the number of Processes, across the whole Orchestrator,
+// which may run at once. Zero or less removes the limit.
+//
+// Limits may be changed at any time; lowering a limit does not stop anything
+// already running, but stops anything new from starting until enough has
+// completed
+func (d Orchestrator) SetConcurrency(n int) {
+	d.limiter.setLimit(n)
+}
+
+// SetProcessConcurrency sets the number of runs of a specific Process which
+// may happen at once, such as to stop a heavy Process from overloading whatever
+// it talks to (see WithConcurrency, and SetConcurrency)
+func (d Orchestrator) SetProcessConcurrency(process string, n int) error {
+	p, ok := d.processes.Load(process)
+	if !ok {
+		return UnknownProcessIDError{process: process}
+	}
+
+	p.(*processEntry).limiter.setLimit(n)
+
+	return nil
+}
+
+// SetInputConcurrency sets the number of Events from a specific Input which
+// may be in-flight at once (see WithInputConcurrency, and SetConcurrency)
+func (d Orchestrator) SetInputConcurrency(input string, n int) error {
+	i, ok := d.inputs.Load(input)
+	if !ok {
+		return UnknownInputError{input: input}
+	}
+
+	i.(*inputEntry).limiter.setLimit(n)
+
+	return nil
+}
+
+// limiter bounds the number of things happening at once, like a semaphore,
+// but with a limit which can be changed while in use
+type limiter struct {
+	mutex   sync.Mutex
+	limit   int
+	active  int
+	changed chan struct{}
+}
+
+func newLimiter(limit int) *limiter {
+	return &limiter{
+		limit:   limit,
+		changed: make(chan struct{}),
+	}
+}
+
+// acquire blocks until there is space under the limit, or ctx is cancelled
+func (l *limiter) acquire(ctx context.Context) error {
+	for {
+		l.mutex.Lock()
+		if l.limit <= 0 || l.active < l.limit {
+			l.active++
+			l.mutex.Unlock()
+
+			return nil
+		}
+
+		changed := l.changed
+		l.mutex.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (l *limiter) release() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.active--
+	l.notify()
+}
+
+func (l *limiter) setLimit(n int) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.limit = n
+	l.notify()
+}
+
+// notify wakes anything waiting in acquire, so it can check the limit
+// again. It must be called with mutex held
+func (l *limiter) notify() {
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
+
+// activity counts things in progress, such as the Dispatches to a Process,
+// allowing callers to wait until there are none. A nil activity does nothing
+type activity struct {
+	mutex   sync.Mutex
+	n       int64
+	changed chan struct{}
+}
+
+func newActivity() *activity {
+	return &activity{
+		changed: make(chan struct{}),
+	}
+}
+
+func (a *activity) add() {
+	if a == nil {
+		return
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	a.n++
+}
+
+func (a *activity) done() {
+	if a == nil {
+		return
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	a.n--
+	if a.n == 0 {
+		close(a.changed)
+		a.changed = make(chan struct{})
+	}
+}
+
+func (a *activity) count() int64 {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	return a.n
+}
+
+// wait blocks until nothing is in progress, or ctx is cancelled
+func (a *activity) wait(ctx context.Context) error {
+	for {
+		a.mutex.Lock()
+		if a.n == 0 {
+			a.mutex.Unlock()
+
+			return nil
+		}
+
+		changed := a.changed
+		a.mutex.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// flight tracks an Event from an Input through every Dispatch it causes,
+// including those of child Processes, releasing the Event's slot in the
+// Input's limiter once they have all completed.
+//
+// A nil flight, such as for Dispatches which didn't come from an Input, does
+// nothing
+type flight struct {
+	input   string
+	pending atomic.Int64
+	release func()
+}
+
+// startFlight waits for space in an Input's limiter, returning a flight
+// holding that space
+func (d Orchestrator) startFlight(ctx context.Context, input string) (*flight, error) {
+	i, ok := d.inputs.Load(input)
+	if !ok {
+		return nil, nil
+	}
+
+	ie := i.(*inputEntry)
+
+	err := ie.limiter.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ie.inFlight.add()
+
+	f := &flight{input: input, release: func() {
+		ie.inFlight.done()
+		ie.limiter.release()
+	}}
+	f.add()
+
+	return f, nil
+}
+
+// inputID returns the ID of the Input this flight started from
+func (f *flight) inputID() string {
+	if f == nil {
+		return ""
+	}
+
+	return f.input
+}
+
+func (f *flight) add() {
+	if f != nil {
+		f.pending.Add(1)
+	}
+}
+
+func (f *flight) done() {
+	if f != nil && f.pending.Add(-1) == 0 {
+		f.release()
+	}
+}
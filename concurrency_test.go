@@ -0,0 +1,182 @@
+package orchestrator_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dapper-data/dapper-orchestrator"
+)
+
+// gatedProcess blocks each run until released
+type gatedProcess struct {
+	id      string
+	started chan string
+	release chan struct{}
+}
+
+func newGatedProcess(id string) *gatedProcess {
+	return &gatedProcess{
+		id:      id,
+		started: make(chan string, 100),
+		release: make(chan struct{}),
+	}
+}
+
+func (p *gatedProcess) Run(ctx context.Context, ev orchestrator.Event) (orchestrator.ProcessStatus, error) {
+	p.started <- ev.ID
+
+	select {
+	case <-p.release:
+	case <-ctx.Done():
+		return orchestrator.ProcessStatus{Name: p.id, Status: orchestrator.ProcessFail}, ctx.Err()
+	}
+
+	return orchestrator.ProcessStatus{Name: p.id, Status: orchestrator.ProcessSuccess}, nil
+}
+
+func (p *gatedProcess) ID() string {
+	return p.id
+}
+
+// expectStarts waits for n runs to start, and then ensures no more do
+func (p *gatedProcess) expectStarts(t *testing.T, n int) {
+	t.Helper()
+
+	for i := 0; i < n; i++ {
+		select {
+		case <-p.started:
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for %s run %d to start", p.id, i+1)
+		}
+	}
+
+	select {
+	case id := <-p.started:
+		t.Fatalf("%s unexpectedly started with %q", p.id, id)
+
+	case <-time.After(time.Millisecond * 50):
+	}
+}
+
+func newSequence(id string, n int) sequenceInput {
+	i := sequenceInput{id: id}
+	for j := 0; j < n; j++ {
+		i.events = append(i.events, orchestrator.Event{ID: fmt.Sprint(j)})
+	}
+
+	return i
+}
+
+func TestOrchestrator_SetProcessConcurrency(t *testing.T) {
+	p := newGatedProcess("gated")
+
+	d := setupOrchestrator(t, testDAG{
+		process:     p,
+		processOpts: []orchestrator.ProcessOption{orchestrator.WithConcurrency(1)},
+		inputs:      []orchestrator.Input{newSequence("sequence-input", 4)},
+	})
+
+	p.expectStarts(t, 1)
+
+	err := d.SetProcessConcurrency(p.ID(), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p.expectStarts(t, 2)
+
+	p.release <- struct{}{}
+	p.expectStarts(t, 1)
+
+	close(p.release)
+}
+
+func TestOrchestrator_SetConcurrency(t *testing.T) {
+	a := newGatedProcess("a")
+	b := newGatedProcess("b")
+
+	// a runs for both events, filling the global limit, and so b
+	// can't run until a completes
+	d := setupOrchestrator(t, testDAG{
+		before:   func(d *orchestrator.Orchestrator) { d.SetConcurrency(2) },
+		process:  a,
+		children: []orchestrator.Process{b},
+		inputs:   []orchestrator.Input{newSequence("sequence-input", 2)},
+	})
+
+	a.expectStarts(t, 2)
+
+	a.release <- struct{}{}
+	b.expectStarts(t, 1)
+
+	d.SetConcurrency(0)
+	a.release <- struct{}{}
+	b.expectStarts(t, 1)
+
+	close(a.release)
+	close(b.release)
+}
+
+func TestOrchestrator_WithInputConcurrency(t *testing.T) {
+	i := newSequence("sequence-input", 3)
+	a := newGatedProcess("a")
+	b := newGatedProcess("b")
+
+	d := setupOrchestrator(t, testDAG{
+		process:   a,
+		children:  []orchestrator.Process{b},
+		inputs:    []orchestrator.Input{i},
+		inputOpts: []orchestrator.InputOption{orchestrator.WithInputConcurrency(1)},
+	})
+
+	// The first event remains in-flight until b completes, and so
+	// the second event doesn't reach a until then
+	a.expectStarts(t, 1)
+
+	a.release <- struct{}{}
+	b.expectStarts(t, 1)
+	a.expectStarts(t, 0)
+
+	b.release <- struct{}{}
+	a.expectStarts(t, 1)
+
+	err := d.SetInputConcurrency(i.ID(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a.expectStarts(t, 1)
+
+	close(a.release)
+	close(b.release)
+}
+
+func TestOrchestrator_SetConcurrency_Unknown(t *testing.T) {
+	d := setupOrchestrator(t, testDAG{})
+
+	err := d.SetProcessConcurrency("missing", 1)
+
+	expectProcess := orchestrator.NewTestUnknownProcessIDError("missing")
+	if !errors.Is(err, expectProcess) {
+		t.Errorf("expected %#v, received %#v", expectProcess, err)
+	}
+
+	err = d.SetInputConcurrency("missing", 1)
+
+	expect := orchestrator.NewTestUnknownInputError("missing")
+	if !errors.Is(err, expect) {
+		t.Errorf("expected %#v, received %#v", expect, err)
+	}
+}
+
+func TestUnknownProcessIDError_Error(t *testing.T) {
+	expect := `process "missing" is unknown`
+	err := orchestrator.NewTestUnknownProcessIDError("missing")
+
+	if expect != err.Error() {
+		t.Errorf("expected\n%s\nreceived\n%s", expect, err.Error())
+	}
+}
@@ -3,14 +3,22 @@ package orchestrator
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"slices"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/heimdalr/dag"
-	"golang.org/x/sync/semaphore"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
-// ConcurrentProcessors limits the number of processes which can be kicked off
-// at once
+// ConcurrentProcessors is the number of processes which can be kicked off
+// at once, across an Orchestrator, when it is created with New.
+//
+// Deprecated: use WithGlobalConcurrency or Orchestrator.SetConcurrency, which
+// affect only a single Orchestrator
 var ConcurrentProcessors int64 = 8
 
 // ProcessInterfaceConversionError returns when trying to load a process from our
@@ -69,19 +77,76 @@ type Orchestrator struct {
 	*dag.DAG
 	inputs    *sync.Map
 	processes *sync.Map
-	wg        *semaphore.Weighted
+	links     *sync.Map
+	limiter   *limiter
+
+	logger         *slog.Logger
+	clock          Clock
+	newID          IDGenerator
+	defaultTimeout time.Duration
+	reporter       *errorReporter
+	metrics        *metrics
+	runStore       RunStore
+	tracer         trace.Tracer
+	propagator     propagation.TextMapPropagator
+
+	// ctx is cancelled when the Orchestrator begins shutting down, and
+	// is the parent of every Input's context. processCtx is cancelled
+	// once shutdown gives up waiting on in-flight processes
+	ctx           context.Context
+	cancel        context.CancelFunc
+	processCtx    context.Context
+	processCancel context.CancelFunc
+
+	dispatches     *dispatchTracker
+	deadLetterSink *atomic.Pointer[DeadLetterSink]
+	journal        *atomic.Pointer[Journal]
+	closed         *atomic.Bool
+	done           chan struct{}
 
+	// ErrorChan receives every error passed to the ErrorHandler. It is
+	// buffered, and where it fills, errors are dropped according to the
+	// Orchestrator's DropPolicy (see WithErrorDropPolicy)
 	ErrorChan chan error
 }
 
-// New returns an Orchestrator ready for use
-func New() *Orchestrator {
+// New returns an Orchestrator ready for use, configured with opts
+func New(opts ...Option) *Orchestrator {
+	o := newOptions(opts)
+
+	var m *metrics
+	if o.registry != nil {
+		m = newMetrics(o.registry)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	processCtx, processCancel := context.WithCancel(context.Background())
+
 	return &Orchestrator{
-		DAG:       dag.NewDAG(),
-		inputs:    new(sync.Map),
-		processes: new(sync.Map),
-		wg:        semaphore.NewWeighted(ConcurrentProcessors),
-		ErrorChan: make(chan error),
+		DAG:            dag.NewDAG(),
+		inputs:         new(sync.Map),
+		processes:      new(sync.Map),
+		links:          new(sync.Map),
+		limiter:        newLimiter(o.concurrency),
+		logger:         o.logger,
+		clock:          o.clock,
+		newID:          o.newID,
+		defaultTimeout: o.defaultTimeout,
+		ctx:            ctx,
+		cancel:         cancel,
+		processCtx:     processCtx,
+		processCancel:  processCancel,
+		dispatches:     newDispatchTracker(),
+		deadLetterSink: new(atomic.Pointer[DeadLetterSink]),
+		journal:        new(atomic.Pointer[Journal]),
+		closed:         new(atomic.Bool),
+		done:           make(chan struct{}),
+		reporter:       &errorReporter{handler: o.errorHandler, policy: o.dropPolicy},
+		metrics:        m,
+		runStore:       o.runStore,
+		tracer:         o.tracerProvider.Tracer(tracerName),
+		propagator:     o.propagator,
+		ErrorChan:      make(chan error, o.errorBuffer),
 	}
 }
 
@@ -89,25 +154,81 @@ func New() *Orchestrator {
 // ready for events to flow through
 //
 // AddInput will error when duplicate input IDs are specified. Any other error
-// from the running of an Input comes via the Orchestrator's ErrorChan - this is
-// because Inputs are run in separate goroutines
-func (d *Orchestrator) AddInput(ctx context.Context, i Input) (err error) {
+// from the running of an Input comes via the Orchestrator's ErrorHandler and
+// ErrorChan - this is because Inputs are run in separate goroutines.
+//
+// Inputs whose Handle function returns before their context is cancelled are
+// restarted according to their RestartPolicy (see WithRestartPolicy), with
+// each failure reported on the ErrorChan as an InputFailedError.
+//
+// Events whose Operation is not one of those passed to WithOperations are
+// dropped before reaching any Process
+//
+// The context passed to the Input's Handle function is derived from ctx, and is
+// additionally cancelled when the Orchestrator is shut down
+func (d *Orchestrator) AddInput(ctx context.Context, i Input, opts ...InputOption) (err error) {
+	ie, err := d.addInput(i, opts)
+	if err != nil {
+		return
+	}
+
+	d.startInput(ctx, ie)
+
+	return
+}
+
+// addInput adds an Input to the DAG without running it, allowing for links
+// to be created before any Events flow
+func (d Orchestrator) addInput(i Input, opts []InputOption) (ie *inputEntry, err error) {
+	if d.closed.Load() {
+		return nil, ErrOrchestratorClosed
+	}
+
 	id := i.ID()
-	d.inputs.Store(id, i)
 
 	err = d.AddVertexByID(id, id)
 	if err != nil {
 		return
 	}
 
+	o := newInputOptions(opts)
+
+	ie = &inputEntry{
+		Input:           i,
+		restartPolicy:   o.restartPolicy,
+		operationFilter: operationFilter{operations: o.operations},
+		limiter:         newLimiter(o.concurrency),
+		gate:            newGate(),
+		inFlight:        newActivity(),
+	}
+
+	ie.removed, ie.remove = context.WithCancel(context.Background())
+
+	d.inputs.Store(id, ie)
+
+	return
+}
+
+func (d Orchestrator) startInput(ctx context.Context, ie *inputEntry) {
+	ictx, cancel := context.WithCancel(ctx)
+	context.AfterFunc(d.ctx, cancel)
+	context.AfterFunc(ie.removed, cancel)
+
 	c := make(chan Event)
+
+	ie.running.Add(2)
+
 	go func() {
-		panic(i.Handle(ctx, c))
+		defer ie.running.Done()
+
+		d.superviseInput(ictx, cancel, ie, c)
 	}()
 
-	go d.runInput(id, c)
+	go func() {
+		defer ie.running.Done()
 
-	return
+		d.runInput(ictx, ie, c)
+	}()
 }
 
 // AddProcess adds a Process to the Orchestrator's DAG, ready to be triggered
@@ -119,66 +240,512 @@ func (d *Orchestrator) AddInput(ctx context.Context, i Input) (err error) {
 // This means long running processes with state should either be re-architected to use
 // some kind of persistence level, or should be a separate service which exposes (say)
 // a webhook or similar trigger
-func (d Orchestrator) AddProcess(p Process) error {
+//
+// Processes with multiple parents are run according to their JoinPolicy (see
+// WithJoinPolicy), and Processes which return errors are retried according to
+// their RetryPolicy (see WithRetryPolicy)
+func (d Orchestrator) AddProcess(p Process, opts ...ProcessOption) (err error) {
+	id := p.ID()
+
+	pe, err := d.newProcessEntry(p, opts)
+	if err != nil {
+		return
+	}
+
+	err = d.AddVertexByID(id, id)
+	if err != nil {
+		return
+	}
+
+	d.processes.Store(id, pe)
+
+	return
+}
+
+// newProcessEntry wraps p with the state, configured by opts, which the
+// Orchestrator needs in order to run it
+func (d Orchestrator) newProcessEntry(p Process, opts []ProcessOption) (*processEntry, error) {
 	id := p.ID()
-	d.processes.Store(id, p)
 
-	return d.AddVertexByID(id, id)
+	o := newProcessOptions(opts)
+	if o.timeout == 0 {
+		o.timeout = d.defaultTimeout
+	}
+
+	err := o.joinPolicy.validate()
+	if err != nil {
+		return nil, fmt.Errorf("process %q: %w", id, err)
+	}
+
+	return &processEntry{
+		Process: p,
+		join: newJoiner(o.joinPolicy, d.clock, func(key string, parents []string) {
+			d.reportError(JoinExpiredError{
+				Process: id,
+				Key:     key,
+				Parents: parents,
+			})
+		}),
+		retryPolicy: o.retryPolicy,
+		timeout:     o.timeout,
+		limiter:     newLimiter(o.concurrency),
+		inFlight:    newActivity(),
+	}, nil
 }
 
 // AddLink accepts an Input and a Process, and links them so that when the
 // input triggers an event, the specified process is called
-func (d Orchestrator) AddLink(input Input, process Process) (err error) {
-	return d.AddEdge(input.ID(), process.ID())
+func (d Orchestrator) AddLink(input Input, process Process, opts ...LinkOption) (err error) {
+	return d.addLink(input.ID(), process.ID(), opts)
+}
+
+// AddProcessLink accepts two Processes, and links them so that when parent
+// completes successfully, child is called. This allows for multi-stage pipelines
+// to be modelled as a single DAG.
+//
+// child receives the Event which triggered parent, or the Event parent sets on
+// its ProcessStatus, with the Event's Trigger set to the ID of parent
+func (d Orchestrator) AddProcessLink(parent, child Process, opts ...LinkOption) (err error) {
+	return d.addLink(parent.ID(), child.ID(), opts)
+}
+
+func (d Orchestrator) addLink(parent, child string, opts []LinkOption) (err error) {
+	err = d.AddEdge(parent, child)
+	if err != nil {
+		return
+	}
+
+	o := newLinkOptions(opts)
+
+	d.links.Store(linkKey{parent: parent, child: child}, &linkEntry{
+		operationFilter: operationFilter{operations: o.operations},
+	})
+
+	return
+}
+
+// FilteredCount returns the number of Events dropped by an Input's operations
+// filter (see WithOperations)
+func (d Orchestrator) FilteredCount(input string) uint64 {
+	i, ok := d.inputs.Load(input)
+	if !ok {
+		return 0
+	}
+
+	return i.(*inputEntry).filtered.Load()
+}
+
+// FilteredLinkCount returns the number of Events dropped by the operations
+// filter of the link between parent and process (see WithLinkOperations)
+func (d Orchestrator) FilteredLinkCount(parent, process string) uint64 {
+	l, ok := d.links.Load(linkKey{parent: parent, child: process})
+	if !ok {
+		return 0
+	}
+
+	return l.(*linkEntry).filtered.Load()
+}
+
+func (d Orchestrator) runInput(ctx context.Context, ie *inputEntry, c chan Event) {
+	id := ie.ID()
+
+	for {
+		// Paused Inputs aren't read from, leaving them blocked
+		// on sending until they're resumed
+		paused, changed := ie.gate.state()
+		if paused {
+			select {
+			case <-ctx.Done():
+				return
+
+			case <-changed:
+				continue
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-changed:
+			continue
+
+		case event := <-c:
+			d.metrics.eventReceived(id)
+
+			if !ie.allow(event) {
+				continue
+			}
+
+			event = d.traceEmit(id, event)
+
+			f, err := d.startFlight(ctx, id)
+			if err != nil {
+				return
+			}
+
+			ok := d.dispatch(id, event, false, f)
+			f.done()
+
+			if !ok {
+				// We're shutting down, and so can't accept
+				// any more work
+				return
+			}
+		}
+	}
 }
 
-func (d Orchestrator) runInput(id string, c chan Event) {
-	for event := range c {
-		children, err := d.GetChildren(id)
-		if err != nil {
+// dispatch runs each child of parent against event, returning false if the
+// Orchestrator is shutting down and so is no longer accepting work.
+//
+// followOn denotes that this dispatch is part of an Event which has already
+// been accepted, and so should run even when shutting down. f is the flight
+// of the Input Event which led to this dispatch
+func (d Orchestrator) dispatch(parent string, event Event, followOn bool, f *flight) bool {
+	children, err := d.GetChildren(parent)
+	if err != nil {
+		return true
+	}
+
+	for k := range children {
+		l, ok := d.links.Load(linkKey{parent: parent, child: k})
+		if ok && !l.(*linkEntry).allow(event) {
+			continue
+		}
+
+		if !d.joined(parent, k, event) {
 			continue
 		}
 
-		for k := range children {
-			go func() {
-				err = d.runChild(id, k, event)
-				if err != nil {
-					d.ErrorChan <- err
-				}
-			}()
+		dispatch := Dispatch{
+			ID:      d.newID(),
+			Parent:  parent,
+			Process: k,
+			Event:   event,
+		}
+
+		d.metrics.dispatched(parent, k)
+		f.add()
+
+		if !d.run(dispatch, d.journalAppend(dispatch), followOn, f) {
+			f.done()
+
+			return false
 		}
 	}
+
+	return true
 }
 
-func (d Orchestrator) runChild(inputID string, child string, event Event) error {
-	d.wg.Acquire(context.Background(), 1)
-	defer d.wg.Release(1)
+// run starts a Dispatch in the background, returning false if the
+// Orchestrator is shutting down. entry is the ID of the Dispatch in the
+// Journal, if any, to acknowledge once it completes
+func (d Orchestrator) run(dispatch Dispatch, entry uint64, followOn bool, f *flight) bool {
+	ref, ok := d.dispatches.add(dispatch, followOn)
+	if !ok {
+		return false
+	}
+
+	// Count the Dispatch against the Process which is current now, so
+	// that RemoveProcess and ReplaceProcess can wait on it
+	var a *activity
+	if pe, ok := d.loadProcess(dispatch.Process); ok {
+		a = pe.inFlight
+		a.add()
+	}
 
+	go d.runDispatch(ref, entry, dispatch, a, f)
+
+	return true
+}
+
+// joined returns true when child should be run as a result of being
+// triggered by parent, according to child's JoinPolicy
+func (d Orchestrator) joined(parent, child string, event Event) bool {
 	process, ok := d.processes.Load(child)
 	if !ok {
-		return UnknownProcessError{
+		// Let runChild deal with reporting this
+		return true
+	}
+
+	pe, ok := process.(*processEntry)
+	if !ok || pe.join.mode() == JoinAny {
+		return true
+	}
+
+	parents, err := d.GetParents(child)
+	if err != nil {
+		return false
+	}
+
+	return pe.join.arrive(parent, event, len(parents))
+}
+
+func (d Orchestrator) runDispatch(ref, entry uint64, dispatch Dispatch, a *activity, f *flight) {
+	defer f.done()
+	defer a.done()
+
+	logger := d.logger.With(
+		slog.String("input", f.inputID()),
+		slog.String("parent", dispatch.Parent),
+		slog.String("process", dispatch.Process),
+		slog.String("dispatch_id", dispatch.ID),
+		slog.String("event_id", dispatch.Event.ID),
+		slog.String("trigger", dispatch.Event.Trigger),
+	)
+
+	ctx, span := d.tracer.Start(d.traceContext(context.Background(), dispatch.Event), "dispatch "+dispatch.Process,
+		trace.WithAttributes(
+			attrInput.String(f.inputID()),
+			attrParent.String(dispatch.Parent),
+			attrProcess.String(dispatch.Process),
+			attrDispatchID.String(dispatch.ID),
+			attrEventID.String(dispatch.Event.ID),
+		),
+	)
+
+	traced := dispatch
+	traced.Event = d.withTraceContext(ctx, dispatch.Event)
+
+	status, attempt, err := d.runAttempts(logger, traced, f.inputID())
+
+	span.SetAttributes(attrAttempt.Int(attempt), attrStatus.String(status.Status.String()))
+	endSpan(span, err)
+
+	if err == nil && status.Status == ProcessSuccess {
+		next := dispatch.Event
+		if status.Event != nil {
+			next = *status.Event
+		}
+
+		next.Trigger = dispatch.Process
+		next = d.withTraceContext(ctx, next)
+
+		// Children are journalled by dispatch before this Dispatch
+		// is acknowledged, so that nothing is lost in between
+		d.dispatch(dispatch.Process, next, true, f)
+	}
+
+	// Processes can fail without returning an error, and those Events
+	// still need dead lettering
+	switch {
+	case err == nil && status.Status == ProcessSuccess:
+		d.journalAck(dispatch, entry)
+
+	case d.processCtx.Err() != nil:
+		// This Dispatch was abandoned on shutdown, rather than
+		// failing, and so is left in the Journal to be replayed
+
+	case d.deadLetter(dispatch, status, err):
+		d.journalAck(dispatch, entry)
+	}
+
+	d.dispatches.remove(ref)
+
+	if err != nil {
+		d.reportError(ProcessError{
+			Input:   f.inputID(),
+			Parent:  dispatch.Parent,
+			Process: dispatch.Process,
+			EventID: dispatch.Event.ID,
+			Attempt: attempt,
+			Err:     err,
+		})
+	}
+}
+
+// runAttempts runs a Dispatch, retrying according to the Process' RetryPolicy,
+// and returning the result of the final attempt
+func (d Orchestrator) runAttempts(logger *slog.Logger, dispatch Dispatch, input string) (status ProcessStatus, attempt int, err error) {
+	var policy RetryPolicy
+
+	process, ok := d.processes.Load(dispatch.Process)
+	if ok {
+		if pe, ok := process.(*processEntry); ok {
+			policy = pe.retryPolicy
+		}
+	}
+
+	for attempt = 1; ; attempt++ {
+		status, err = d.runChild(logger, dispatch, input, attempt)
+		if err == nil || !policy.retry(attempt, err) {
+			return
+		}
+
+		select {
+		case <-d.processCtx.Done():
+			return
+
+		case <-d.clock.After(policy.Backoff.Duration(attempt - 1)):
+		}
+	}
+}
+
+// runChild runs a single attempt of a Dispatch, where input is the ID of the
+// Input whose Event led to it, if any
+func (d Orchestrator) runChild(logger *slog.Logger, dispatch Dispatch, input string, attempt int) (status ProcessStatus, err error) {
+	inputID, child, event := dispatch.Parent, dispatch.Process, dispatch.Event
+
+	process, ok := d.processes.Load(child)
+	if !ok {
+		err = UnknownProcessError{
 			input:   inputID,
 			process: child,
 		}
+
+		return
 	}
 
-	pp, ok := process.(Process)
+	pe, ok := process.(*processEntry)
 	if !ok {
-		return ProcessInterfaceConversionError{
+		err = ProcessInterfaceConversionError{
 			input:   inputID,
 			process: child,
 			iface:   process,
 		}
+
+		return
 	}
 
-	status, err := pp.Run(context.Background(), event)
+	spanCtx := d.traceContext(context.Background(), event)
+	_, waitSpan := d.tracer.Start(spanCtx, "wait "+child, trace.WithAttributes(attrProcess.String(child)))
+
+	// Acquire the process' own limit first, so as not to hold a slot
+	// in the global limit while waiting on it
+	queued := d.clock.Now()
+
+	err = pe.limiter.acquire(d.processCtx)
+	if err != nil {
+		endSpan(waitSpan, err)
+
+		return
+	}
+
+	defer pe.limiter.release()
+
+	err = d.limiter.acquire(d.processCtx)
 	if err != nil {
-		return err
+		endSpan(waitSpan, err)
+
+		return
 	}
 
+	defer d.limiter.release()
+
+	waitSpan.End()
+	d.metrics.queued(child, d.clock.Now().Sub(queued))
+
+	runCtx, runSpan := d.tracer.Start(spanCtx, "run "+child, trace.WithAttributes(
+		attrProcess.String(child),
+		attrAttempt.Int(attempt),
+	))
+
+	ctx, event := withAttempt(trace.ContextWithSpan(d.processCtx, runSpan), event, attempt, pe.retryPolicy)
+	event = d.withTraceContext(runCtx, event)
+
+	logger = logger.With(slog.Int("attempt", attempt))
+	logger.Debug("process started")
+
+	finished := d.metrics.started(child)
+
+	start := d.clock.Now()
+	status, err = pe.run(ctx, event)
+	duration := d.clock.Now().Sub(start)
+
+	finished(status.Status, duration)
+
+	last := status.Status
+	pe.lastStatus.Store(&last)
+
+	d.saveRun(Run{
+		ID:         d.newID(),
+		DispatchID: dispatch.ID,
+		Input:      input,
+		Parent:     dispatch.Parent,
+		Process:    child,
+		Event:      event,
+		Start:      start,
+		End:        start.Add(duration),
+		Attempt:    attempt,
+		Status:     status.Status,
+		Logs:       status.Logs,
+		Error:      errorString(err),
+	})
+
+	runSpan.SetAttributes(attrStatus.String(status.Status.String()))
+	endSpan(runSpan, err)
+
 	for _, l := range status.Logs {
-		fmt.Printf("%s -> %s\n", status.Name, l)
+		logger.Info("process log", slog.String("log", l))
 	}
 
-	return nil
+	if err != nil {
+		logger.Warn("process failed", slog.Duration("duration", duration), slog.Any("error", err))
+
+		return
+	}
+
+	logger.Info("process finished", slog.String("status", status.Status.String()), slog.Duration("duration", duration))
+
+	return
+}
+
+// processEntry wraps a Process with the state the Orchestrator needs
+// in order to run it
+type processEntry struct {
+	Process
+
+	join        *joiner
+	retryPolicy RetryPolicy
+	timeout     time.Duration
+	limiter     *limiter
+	inFlight    *activity
+	lastStatus  atomic.Pointer[ProcessExitStatus]
+}
+
+// inputEntry wraps an Input with the state the Orchestrator needs
+// in order to run it
+type inputEntry struct {
+	Input
+
+	restartPolicy RestartPolicy
+	operationFilter
+	limiter  *limiter
+	gate     *gate
+	state    atomic.Int32
+	inFlight *activity
+
+	// removed is cancelled by RemoveInput, stopping the Input, after
+	// which running completes
+	removed context.Context
+	remove  context.CancelFunc
+	running sync.WaitGroup
+}
+
+// linkKey identifies a link between an Input or Process, and a Process
+type linkKey struct {
+	parent, child string
+}
+
+// linkEntry contains the configuration of a link
+type linkEntry struct {
+	operationFilter
+}
+
+// operationFilter drops Events whose Operation is not in operations,
+// counting those it drops. An empty set of operations allows everything
+type operationFilter struct {
+	operations []Operation
+	filtered   atomic.Uint64
+}
+
+func (f *operationFilter) allow(e Event) bool {
+	if len(f.operations) == 0 || slices.Contains(f.operations, e.Operation) {
+		return true
+	}
+
+	f.filtered.Add(1)
+
+	return false
 }
@@ -3,6 +3,7 @@ package orchestrator_test
 import (
 	"context"
 	"reflect"
+	"slices"
 	"testing"
 	"time"
 
@@ -109,3 +110,325 @@ func TestUnknownProcessError_Error(t *testing.T) {
 	}
 
 }
+
+// recordingProcess sends every Event it receives down a channel, returning
+// the specified status
+type recordingProcess struct {
+	id     string
+	status orchestrator.ProcessStatus
+	events chan orchestrator.Event
+}
+
+func newRecordingProcess(id string, status orchestrator.ProcessExitStatus) *recordingProcess {
+	return &recordingProcess{
+		id:     id,
+		status: orchestrator.ProcessStatus{Name: id, Status: status},
+		events: make(chan orchestrator.Event, 10),
+	}
+}
+
+func (p *recordingProcess) Run(_ context.Context, ev orchestrator.Event) (orchestrator.ProcessStatus, error) {
+	p.events <- ev
+
+	return p.status, nil
+}
+
+func (p *recordingProcess) ID() string {
+	return p.id
+}
+
+func (p *recordingProcess) next(t *testing.T) orchestrator.Event {
+	t.Helper()
+
+	select {
+	case ev := <-p.events:
+		return ev
+
+	case <-time.After(time.Second):
+		t.Fatalf("timed out waiting for %s to run", p.id)
+	}
+
+	return orchestrator.Event{}
+}
+
+func (p *recordingProcess) expectNoRun(t *testing.T) {
+	t.Helper()
+
+	select {
+	case ev := <-p.events:
+		t.Errorf("%s unexpectedly ran with %#v", p.id, ev)
+
+	case <-time.After(time.Millisecond * 50):
+	}
+}
+
+// testDAG describes an Orchestrator for setupOrchestrator to build, in which
+// process, where set, is linked to each of inputs, and to each of children
+type testDAG struct {
+	opts []orchestrator.Option
+
+	// before is called with the new Orchestrator, before anything is
+	// added to it
+	before func(*orchestrator.Orchestrator)
+
+	process     orchestrator.Process
+	processOpts []orchestrator.ProcessOption
+	children    []orchestrator.Process
+
+	inputs    []orchestrator.Input
+	inputOpts []orchestrator.InputOption
+	linkOpts  []orchestrator.LinkOption
+
+	// paused contains the IDs of inputs to pause before they're linked
+	paused []string
+}
+
+// setupOrchestrator builds the Orchestrator described by dag, which is shut
+// down once the test, and any of its subtests, complete
+func setupOrchestrator(t *testing.T, dag testDAG) *orchestrator.Orchestrator {
+	t.Helper()
+
+	d := orchestrator.New(dag.opts...)
+
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		// Tests which shut the Orchestrator down themselves
+		// leave this returning ErrOrchestratorClosed
+		d.Shutdown(ctx)
+	})
+
+	if dag.before != nil {
+		dag.before(d)
+	}
+
+	if dag.process == nil {
+		return d
+	}
+
+	err := d.AddProcess(dag.process, dag.processOpts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, child := range dag.children {
+		err = d.AddProcess(child)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = d.AddProcessLink(dag.process, child)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, i := range dag.inputs {
+		err = d.AddInput(context.Background(), i, dag.inputOpts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if slices.Contains(dag.paused, i.ID()) {
+			err = d.PauseInput(i.ID())
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		err = d.AddLink(i, dag.process, dag.linkOpts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return d
+}
+
+func TestOrchestrator_AddProcessLink(t *testing.T) {
+	d := orchestrator.New()
+
+	i := onceInput{id: "once-input"}
+	raw := newRecordingProcess("raw", orchestrator.ProcessSuccess)
+	cleansed := newRecordingProcess("cleansed", orchestrator.ProcessSuccess)
+	reporting := newRecordingProcess("reporting", orchestrator.ProcessSuccess)
+
+	for _, p := range []orchestrator.Process{raw, cleansed, reporting} {
+		err := d.AddProcess(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := d.AddProcessLink(raw, cleansed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = d.AddProcessLink(cleansed, reporting)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = d.AddInput(context.Background(), i)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = d.AddLink(i, raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		p             *recordingProcess
+		expectTrigger string
+	}{
+		{raw, "once-input"},
+		{cleansed, "raw"},
+		{reporting, "cleansed"},
+	} {
+		t.Run(test.p.id, func(t *testing.T) {
+			ev := test.p.next(t)
+
+			if ev.ID != "1" {
+				t.Errorf("expected event ID %q, received %q", "1", ev.ID)
+			}
+
+			if ev.Trigger != test.expectTrigger {
+				t.Errorf("expected trigger %q, received %q", test.expectTrigger, ev.Trigger)
+			}
+		})
+	}
+}
+
+func TestOrchestrator_AddProcessLink_Failure(t *testing.T) {
+	d := orchestrator.New()
+
+	i := onceInput{id: "once-input"}
+	parent := newRecordingProcess("parent", orchestrator.ProcessFail)
+	child := newRecordingProcess("child", orchestrator.ProcessSuccess)
+
+	for _, p := range []orchestrator.Process{parent, child} {
+		err := d.AddProcess(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := d.AddProcessLink(parent, child)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = d.AddInput(context.Background(), i)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = d.AddLink(i, parent)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parent.next(t)
+	child.expectNoRun(t)
+}
+
+// sequenceInput sends each of its events, in order, and then waits to be
+// told to stop
+type sequenceInput struct {
+	id     string
+	events []orchestrator.Event
+}
+
+func (i sequenceInput) Handle(ctx context.Context, c chan orchestrator.Event) error {
+	time.Sleep(time.Millisecond * 10)
+
+	for _, ev := range i.events {
+		select {
+		case c <- ev:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	<-ctx.Done()
+
+	return ctx.Err()
+}
+
+func (i sequenceInput) ID() string {
+	return i.id
+}
+
+func TestOrchestrator_Operations(t *testing.T) {
+	d := orchestrator.New()
+
+	i := sequenceInput{
+		id: "crud-input",
+		events: []orchestrator.Event{
+			{ID: "1", Operation: orchestrator.OperationCreate},
+			{ID: "2", Operation: orchestrator.OperationRead},
+			{ID: "3", Operation: orchestrator.OperationUpdate},
+			{ID: "4", Operation: orchestrator.OperationDelete},
+		},
+	}
+
+	all := newRecordingProcess("all", orchestrator.ProcessSuccess)
+	inserts := newRecordingProcess("inserts", orchestrator.ProcessSuccess)
+
+	for _, p := range []orchestrator.Process{all, inserts} {
+		err := d.AddProcess(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := d.AddInput(context.Background(), i, orchestrator.WithOperations(orchestrator.OperationCreate, orchestrator.OperationUpdate, orchestrator.OperationDelete))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = d.AddLink(i, all)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = d.AddLink(i, inserts, orchestrator.WithLinkOperations(orchestrator.OperationCreate))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	received := make(map[string]bool)
+	for j := 0; j < 3; j++ {
+		received[all.next(t).ID] = true
+	}
+
+	all.expectNoRun(t)
+
+	expect := map[string]bool{"1": true, "3": true, "4": true}
+	if !reflect.DeepEqual(expect, received) {
+		t.Errorf("expected %v, received %v", expect, received)
+	}
+
+	ev := inserts.next(t)
+	if ev.ID != "1" {
+		t.Errorf("expected event ID %q, received %q", "1", ev.ID)
+	}
+
+	inserts.expectNoRun(t)
+
+	if count := d.FilteredCount("crud-input"); count != 1 {
+		t.Errorf("expected 1 filtered event, received %d", count)
+	}
+
+	if count := d.FilteredLinkCount("crud-input", "inserts"); count != 2 {
+		t.Errorf("expected 2 filtered events, received %d", count)
+	}
+
+	if count := d.FilteredLinkCount("crud-input", "all"); count != 0 {
+		t.Errorf("expected 0 filtered events, received %d", count)
+	}
+}
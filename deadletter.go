@@ -0,0 +1,239 @@
+package orchestrator
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// DeadLetter contains an Event which a Process failed to handle, even after
+// any retries, along with details of that failure
+type DeadLetter struct {
+	// Event is the Event the Process was triggered with
+	Event Event `json:"event"`
+
+	// Parent is the ID of the Input or Process which triggered Process
+	Parent string `json:"parent"`
+
+	// Process is the ID of the Process which failed
+	Process string `json:"process"`
+
+	// Status is the ProcessStatus from the final attempt
+	Status ProcessStatus `json:"status"`
+
+	// Error is the error message from the final attempt, with Err
+	// containing the error itself, where available. Processes which
+	// fail without returning an error leave both empty
+	Error string `json:"error"`
+	Err   error  `json:"-"`
+
+	// Time is when the Event was dead lettered
+	Time time.Time `json:"time"`
+}
+
+// DeadLetterSinkError is sent to the ErrorChan when a DeadLetterSink fails to
+// accept a DeadLetter, and so the Event it contains may be lost
+type DeadLetterSinkError struct {
+	Dispatch Dispatch
+	Err      error
+}
+
+// Error returns a descriptive error message
+func (e DeadLetterSinkError) Error() string {
+	return fmt.Sprintf("dead letter for event %q from %q to %q failed: %s", e.Dispatch.Event.ID, e.Dispatch.Parent, e.Dispatch.Process, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e DeadLetterSinkError) Unwrap() error {
+	return e.Err
+}
+
+// DeadLetterSink receives Events which Processes fail to handle, so that
+// they aren't lost, and can be re-injected later (see Orchestrator.Reinject).
+//
+// The context passed to DeadLetter stays live while a graceful shutdown
+// drains, and is only cancelled once Shutdown gives up waiting
+type DeadLetterSink interface {
+	DeadLetter(context.Context, DeadLetter) error
+}
+
+// SetDeadLetterSink sets the DeadLetterSink which Events are sent to when a
+// Process fails to handle them. Errors from the sink are sent to the ErrorChan.
+//
+// Passing nil disables dead lettering, which is the default
+func (d Orchestrator) SetDeadLetterSink(s DeadLetterSink) {
+	if s == nil {
+		d.deadLetterSink.Store(nil)
+
+		return
+	}
+
+	d.deadLetterSink.Store(&s)
+}
+
+// Trigger runs a Process with an Event directly, bypassing any Input, such as
+// when re-injecting a dead lettered Event. The Process runs in the background,
+// as though it were triggered by an Input, and so triggers any Processes linked
+// to it on success.
+//
+// Trigger returns an error if the Process does not exist, or if the Orchestrator
+// is shutting down
+func (d Orchestrator) Trigger(process string, e Event) error {
+	if _, ok := d.processes.Load(process); !ok {
+		return UnknownProcessError{
+			input:   e.Trigger,
+			process: process,
+		}
+	}
+
+	if d.closed.Load() {
+		return ErrOrchestratorClosed
+	}
+
+	dispatch := Dispatch{
+		ID:      d.newID(),
+		Parent:  e.Trigger,
+		Process: process,
+		Event:   e,
+	}
+
+	if !d.run(dispatch, d.journalAppend(dispatch), false, nil) {
+		return ErrOrchestratorClosed
+	}
+
+	return nil
+}
+
+// Reinject re-runs the Process which failed to handle a DeadLetter (see
+// Trigger)
+func (d Orchestrator) Reinject(dl DeadLetter) error {
+	return d.Trigger(dl.Process, dl.Event)
+}
+
+// deadLetter sends a failed Dispatch to the DeadLetterSink, if one is set,
+// returning true if the sink accepted it. err may be nil, where the Process
+// failed without returning an error
+func (d Orchestrator) deadLetter(dispatch Dispatch, status ProcessStatus, err error) bool {
+	s := d.deadLetterSink.Load()
+	if s == nil {
+		return false
+	}
+
+	dl := DeadLetter{
+		Event:   dispatch.Event,
+		Parent:  dispatch.Parent,
+		Process: dispatch.Process,
+		Status:  status,
+		Err:     err,
+		Time:    d.clock.Now(),
+	}
+
+	if err != nil {
+		dl.Error = err.Error()
+	}
+
+	sinkErr := (*s).DeadLetter(d.processCtx, dl)
+	if sinkErr != nil {
+		d.reportError(DeadLetterSinkError{Dispatch: dispatch, Err: sinkErr})
+
+		return false
+	}
+
+	return true
+}
+
+// FileDeadLetterSink is a DeadLetterSink which appends DeadLetters to a file
+// as newline delimited JSON
+type FileDeadLetterSink struct {
+	mutex sync.Mutex
+	path  string
+	f     *os.File
+}
+
+// NewFileDeadLetterSink opens (or creates) path, ready to have DeadLetters
+// appended to it
+func NewFileDeadLetterSink(path string) (s *FileDeadLetterSink, err error) {
+	s = &FileDeadLetterSink{
+		path: path,
+	}
+
+	s.f, err = os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+
+	return
+}
+
+// DeadLetter appends dl to the file, syncing it to disk before returning
+func (s *FileDeadLetterSink) DeadLetter(_ context.Context, dl DeadLetter) (err error) {
+	b, err := json.Marshal(dl)
+	if err != nil {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	_, err = s.f.Write(append(b, '\n'))
+	if err != nil {
+		return
+	}
+
+	return s.f.Sync()
+}
+
+// DeadLetters reads every DeadLetter in the file, in the order they were
+// written, such as for re-injecting with Orchestrator.Reinject
+func (s *FileDeadLetterSink) DeadLetters() (dls []DeadLetter, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	f, err := os.Open(s.path)
+	if err != nil {
+		return
+	}
+
+	defer f.Close()
+
+	return readDeadLetters(f)
+}
+
+// Close closes the underlying file
+func (s *FileDeadLetterSink) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.f.Close()
+}
+
+func readDeadLetters(r io.Reader) (dls []DeadLetter, err error) {
+	dls = make([]DeadLetter, 0)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), 64<<20)
+
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var dl DeadLetter
+
+		err = json.Unmarshal(scanner.Bytes(), &dl)
+		if err != nil {
+			return
+		}
+
+		if dl.Error != "" {
+			dl.Err = errors.New(dl.Error)
+		}
+
+		dls = append(dls, dl)
+	}
+
+	return dls, scanner.Err()
+}
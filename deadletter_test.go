@@ -0,0 +1,366 @@
+package orchestrator_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dapper-data/dapper-orchestrator"
+)
+
+// failOnceProcess fails the first time it is run, and succeeds thereafter
+type failOnceProcess struct {
+	err    error
+	runs   atomic.Int32
+	events chan orchestrator.Event
+}
+
+func (p *failOnceProcess) Run(_ context.Context, ev orchestrator.Event) (orchestrator.ProcessStatus, error) {
+	p.events <- ev
+
+	if p.runs.Add(1) == 1 {
+		return orchestrator.ProcessStatus{Name: p.ID(), Status: orchestrator.ProcessFail}, p.err
+	}
+
+	return orchestrator.ProcessStatus{Name: p.ID(), Status: orchestrator.ProcessSuccess}, nil
+}
+
+func (p *failOnceProcess) ID() string {
+	return "fail-once-process"
+}
+
+func TestOrchestrator_DeadLetterSink(t *testing.T) {
+	oops := errors.New("oops")
+
+	sink, err := orchestrator.NewFileDeadLetterSink(filepath.Join(t.TempDir(), "dead-letters.ndjson"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer sink.Close()
+
+	p := &failOnceProcess{err: oops, events: make(chan orchestrator.Event, 10)}
+	child := newRecordingProcess("child", orchestrator.ProcessSuccess)
+
+	d := setupOrchestrator(t, testDAG{
+		before: func(d *orchestrator.Orchestrator) {
+			d.SetDeadLetterSink(sink)
+		},
+		process:  p,
+		children: []orchestrator.Process{child},
+		inputs:   []orchestrator.Input{onceInput{id: "once-input"}},
+	})
+
+	select {
+	case err = <-d.ErrorChan:
+		if !errors.Is(err, oops) {
+			t.Fatalf("expected %#v, received %#v", oops, err)
+		}
+
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for error")
+	}
+
+	child.expectNoRun(t)
+
+	dls, err := sink.DeadLetters()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(dls) != 1 {
+		t.Fatalf("expected 1 dead letter, received %d", len(dls))
+	}
+
+	dl := dls[0]
+	expect := orchestrator.Event{ID: "1", Trigger: "once-input"}
+
+	if !reflect.DeepEqual(expect, dl.Event) {
+		t.Errorf("expected %#v, received %#v", expect, dl.Event)
+	}
+
+	if dl.Parent != "once-input" {
+		t.Errorf("expected %q, received %q", "once-input", dl.Parent)
+	}
+
+	if dl.Process != p.ID() {
+		t.Errorf("expected %q, received %q", p.ID(), dl.Process)
+	}
+
+	if dl.Status.Status != orchestrator.ProcessFail {
+		t.Errorf("expected %v, received %v", orchestrator.ProcessFail, dl.Status.Status)
+	}
+
+	if dl.Error != oops.Error() {
+		t.Errorf("expected %q, received %q", oops.Error(), dl.Error)
+	}
+
+	<-p.events
+
+	err = d.Reinject(dl)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case ev := <-p.events:
+		if !reflect.DeepEqual(expect, ev) {
+			t.Errorf("expected %#v, received %#v", expect, ev)
+		}
+
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for reinjected event")
+	}
+
+	ev := child.next(t)
+	if ev.Trigger != p.ID() {
+		t.Errorf("expected %q, received %q", p.ID(), ev.Trigger)
+	}
+}
+
+func TestOrchestrator_Trigger(t *testing.T) {
+	d := orchestrator.New()
+	p := newRecordingProcess("recording", orchestrator.ProcessSuccess)
+
+	err := d.AddProcess(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("unknown process", func(t *testing.T) {
+		err := d.Trigger("missing", orchestrator.Event{ID: "1"})
+
+		var upe orchestrator.UnknownProcessError
+		if !errors.As(err, &upe) {
+			t.Errorf("expected UnknownProcessError, received %#v", err)
+		}
+	})
+
+	t.Run("known process", func(t *testing.T) {
+		err := d.Trigger(p.ID(), orchestrator.Event{ID: "1"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ev := p.next(t)
+		if ev.ID != "1" {
+			t.Errorf("expected %q, received %q", "1", ev.ID)
+		}
+	})
+
+	t.Run("closed orchestrator", func(t *testing.T) {
+		_, err := d.Shutdown(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = d.Trigger(p.ID(), orchestrator.Event{ID: "2"})
+		if !errors.Is(err, orchestrator.ErrOrchestratorClosed) {
+			t.Errorf("expected %#v, received %#v", orchestrator.ErrOrchestratorClosed, err)
+		}
+	})
+}
+
+// contextSink accepts DeadLetters so long as the context it's passed is
+// still live, as a sink which respects its context would
+type contextSink struct {
+	dls chan orchestrator.DeadLetter
+}
+
+func (s contextSink) DeadLetter(ctx context.Context, dl orchestrator.DeadLetter) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.dls <- dl
+
+	return nil
+}
+
+func TestOrchestrator_DeadLetterSink_DuringShutdown(t *testing.T) {
+	oops := errors.New("oops")
+
+	d := orchestrator.New(orchestrator.WithErrorHandler(orchestrator.ErrorHandlerFunc(func(error) {})))
+
+	sink := contextSink{dls: make(chan orchestrator.DeadLetter, 1)}
+	d.SetDeadLetterSink(sink)
+
+	started, release := make(chan struct{}), make(chan struct{})
+	p := processFunc{id: "failing", f: func(context.Context, orchestrator.Event) (orchestrator.ProcessStatus, error) {
+		close(started)
+		<-release
+
+		return orchestrator.ProcessStatus{Status: orchestrator.ProcessFail}, oops
+	}}
+
+	err := d.AddProcess(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = d.Trigger(p.ID(), orchestrator.Event{ID: "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	<-started
+
+	shutdown := make(chan error, 1)
+	go func() {
+		_, err := d.Shutdown(context.Background())
+		shutdown <- err
+	}()
+
+	// Give Shutdown time to stop accepting work, and start draining
+	time.Sleep(time.Millisecond * 20)
+	close(release)
+
+	select {
+	case dl := <-sink.dls:
+		if dl.Event.ID != "1" {
+			t.Errorf("expected %q, received %q", "1", dl.Event.ID)
+		}
+
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for dead letter")
+	}
+
+	err = <-shutdown
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// processFunc is a Process which runs f
+type processFunc struct {
+	id string
+	f  func(context.Context, orchestrator.Event) (orchestrator.ProcessStatus, error)
+}
+
+func (p processFunc) Run(ctx context.Context, ev orchestrator.Event) (orchestrator.ProcessStatus, error) {
+	return p.f(ctx, ev)
+}
+
+func (p processFunc) ID() string {
+	return p.id
+}
+
+// failingSink rejects every DeadLetter
+type failingSink struct {
+	err error
+}
+
+func (s failingSink) DeadLetter(context.Context, orchestrator.DeadLetter) error {
+	return s.err
+}
+
+func TestOrchestrator_DeadLetterSink_StatusOnly(t *testing.T) {
+	// A failed status is enough to be dead lettered, without an error
+	p := processFunc{id: "failing", f: func(context.Context, orchestrator.Event) (orchestrator.ProcessStatus, error) {
+		return orchestrator.ProcessStatus{Name: "failing", Status: orchestrator.ProcessFail}, nil
+	}}
+
+	full := errors.New("sink is full")
+
+	for _, test := range []struct {
+		name        string
+		sink        orchestrator.DeadLetterSink
+		expectError error
+	}{
+		{"accepted", contextSink{dls: make(chan orchestrator.DeadLetter, 1)}, nil},
+		{"rejected", failingSink{err: full}, orchestrator.DeadLetterSinkError{
+			Dispatch: orchestrator.Dispatch{Process: "failing", Event: orchestrator.Event{ID: "1"}},
+			Err:      full,
+		}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			d := setupOrchestrator(t, testDAG{
+				before:  func(d *orchestrator.Orchestrator) { d.SetDeadLetterSink(test.sink) },
+				process: p,
+			})
+
+			err := d.Trigger(p.ID(), orchestrator.Event{ID: "1"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if test.expectError == nil {
+				select {
+				case dl := <-test.sink.(contextSink).dls:
+					if dl.Event.ID != "1" || dl.Status.Status != orchestrator.ProcessFail || dl.Error != "" || dl.Err != nil {
+						t.Errorf("unexpected dead letter %#v", dl)
+					}
+
+				case <-time.After(time.Second):
+					t.Fatal("timed out waiting for dead letter")
+				}
+
+				return
+			}
+
+			select {
+			case err = <-d.ErrorChan:
+			case <-time.After(time.Second):
+				t.Fatal("timed out waiting for error")
+			}
+
+			var dse orchestrator.DeadLetterSinkError
+			if !errors.As(err, &dse) {
+				t.Fatalf("expected DeadLetterSinkError, received %#v", err)
+			}
+
+			// Dispatch IDs are random, and so aren't compared
+			dse.Dispatch.ID = ""
+			if !reflect.DeepEqual(test.expectError, dse) {
+				t.Errorf("expected\n%#v\nreceived\n%#v", test.expectError, dse)
+			}
+
+			if !errors.Is(err, full) {
+				t.Errorf("expected %#v, received %#v", full, err)
+			}
+		})
+	}
+}
+
+func TestDeadLetterSinkError_Error(t *testing.T) {
+	expect := `dead letter for event "1" from "orders" to "failing" failed: sink is full`
+	err := orchestrator.DeadLetterSinkError{
+		Dispatch: orchestrator.Dispatch{Parent: "orders", Process: "failing", Event: orchestrator.Event{ID: "1"}},
+		Err:      errors.New("sink is full"),
+	}
+
+	if expect != err.Error() {
+		t.Errorf("expected\n%s\nreceived\n%s", expect, err.Error())
+	}
+}
+
+func TestFileDeadLetterSink_DeadLetters_NumericStatus(t *testing.T) {
+	// Older versions wrote ProcessExitStatuses as numbers
+	path := filepath.Join(t.TempDir(), "dead-letters.ndjson")
+
+	err := os.WriteFile(path, []byte(`{"event":{"id":"1"},"parent":"orders","process":"failing","status":{"Name":"failing","Logs":null,"Status":3},"error":"oops","time":"2023-11-06T14:00:00Z"}`+"\n"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sink, err := orchestrator.NewFileDeadLetterSink(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer sink.Close()
+
+	dls, err := sink.DeadLetters()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(dls) != 1 || dls[0].Status.Status != orchestrator.ProcessFail {
+		t.Errorf("expected one dead letter with status %s, received %#v", orchestrator.ProcessFail, dls)
+	}
+}
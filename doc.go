@@ -34,7 +34,7 @@ This package is also useful for running pipelines cheaply, or locally- it requir
 
 # When should you _not_ use this package?
 
-This package will not give you the same things that off the shelf tools, such as databricks, will give you. There's no easy way to see DAGs, no simple API for updating configuration (unless you write your own).
+This package will not give you the same things that off the shelf tools, such as databricks, will give you. There's no UI for seeing DAGs, though ExportDOT and ExportMermaid render them for Graphviz and Mermaid and, while AdminHandler exposes a JSON API for inspecting and controlling an Orchestrator, there's nothing on top of it (unless you write your own).
 
 This package wont do a lot of what you might need; it exists to serve as the engine of a pipeline tool; you must build the rest yourself.
 */
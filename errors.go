@@ -0,0 +1,164 @@
+package orchestrator
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+)
+
+// DefaultErrorBuffer is the size of an Orchestrator's ErrorChan, unless set
+// with WithErrorBuffer
+const DefaultErrorBuffer = 64
+
+// ErrorHandler is called with every error an Orchestrator encounters while
+// running, such as a ProcessError when a Process fails, or an InputFailedError
+// when an Input does. HandleError is called from the goroutine which
+// encountered the error, and so should return quickly
+type ErrorHandler interface {
+	HandleError(error)
+}
+
+// ErrorHandlerFunc allows a plain function to be used as an ErrorHandler
+type ErrorHandlerFunc func(error)
+
+// HandleError calls f(err)
+func (f ErrorHandlerFunc) HandleError(err error) {
+	f(err)
+}
+
+// LogErrorHandler is the ErrorHandler used by default, and logs each
+// error to Logger, along with details of any ProcessError
+type LogErrorHandler struct {
+	Logger *slog.Logger
+}
+
+// HandleError logs err
+func (h LogErrorHandler) HandleError(err error) {
+	attrs := []any{slog.Any("error", err)}
+
+	var pe ProcessError
+	if errors.As(err, &pe) {
+		attrs = append(attrs,
+			slog.String("input", pe.Input),
+			slog.String("parent", pe.Parent),
+			slog.String("process", pe.Process),
+			slog.String("event_id", pe.EventID),
+			slog.Int("attempt", pe.Attempt),
+		)
+	}
+
+	h.Logger.Error("orchestrator error", attrs...)
+}
+
+// DropPolicy determines what happens when an error is sent to a full
+// ErrorChan
+type DropPolicy uint8
+
+// Supported set of DropPolicies
+const (
+	// DropNewest discards the error being sent
+	DropNewest DropPolicy = iota
+
+	// DropOldest discards the oldest error in ErrorChan to make space
+	// for the error being sent
+	DropOldest
+
+	// Block waits until ErrorChan is read from, or the Orchestrator
+	// shuts down. Where nothing reads ErrorChan, this stops Processes
+	// from completing
+	Block
+)
+
+// ProcessError is sent to the ErrorHandler and ErrorChan when a Process
+// fails, and contains enough context to route the error without having to
+// parse its message
+type ProcessError struct {
+	// Input is the ID of the Input whose Event led to Process running,
+	// which is empty where Process was triggered directly (see Trigger)
+	Input string
+
+	// Parent is the ID of the Input or Process which triggered Process
+	Parent string
+
+	// Process is the ID of the Process which failed
+	Process string
+
+	// EventID is the ID of the Event Process was triggered with
+	EventID string
+
+	// Attempt is the attempt which failed, with Processes being
+	// retried according to their RetryPolicy
+	Attempt int
+
+	// Err is the error the Process returned
+	Err error
+}
+
+// Error returns a descriptive error message
+func (e ProcessError) Error() string {
+	return fmt.Sprintf("process %q failed handling event %q from %q (attempt %d): %s", e.Process, e.EventID, e.Parent, e.Attempt, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e ProcessError) Unwrap() error {
+	return e.Err
+}
+
+// DroppedErrors returns the number of errors which were dropped, rather
+// than sent to ErrorChan, because it was full (see WithErrorDropPolicy)
+func (d Orchestrator) DroppedErrors() uint64 {
+	return d.reporter.dropped.Load()
+}
+
+// errorReporter holds the configuration and state of error reporting
+type errorReporter struct {
+	handler ErrorHandler
+	policy  DropPolicy
+	dropped atomic.Uint64
+}
+
+// reportError passes err to the ErrorHandler, and then sends it to
+// ErrorChan according to the DropPolicy
+func (d Orchestrator) reportError(err error) {
+	d.reporter.handler.HandleError(err)
+
+	switch d.reporter.policy {
+	case Block:
+		select {
+		case d.ErrorChan <- err:
+		case <-d.processCtx.Done():
+			d.reporter.dropped.Add(1)
+		}
+
+	case DropOldest:
+		for {
+			select {
+			case d.ErrorChan <- err:
+				return
+
+			default:
+			}
+
+			select {
+			case <-d.ErrorChan:
+				d.reporter.dropped.Add(1)
+
+			default:
+				// Unbuffered, with nobody waiting to read
+				if cap(d.ErrorChan) == 0 {
+					d.reporter.dropped.Add(1)
+
+					return
+				}
+			}
+		}
+
+	default:
+		select {
+		case d.ErrorChan <- err:
+		default:
+			d.reporter.dropped.Add(1)
+		}
+	}
+}
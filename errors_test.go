@@ -0,0 +1,188 @@
+package orchestrator_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/dapper-data/dapper-orchestrator"
+)
+
+func TestOrchestrator_WithErrorHandler(t *testing.T) {
+	oops := errors.New("oops")
+	errs := make(chan error, 10)
+
+	p := newFlakyProcess(orchestrator.Retryable(oops), 10)
+
+	setupOrchestrator(t, testDAG{
+		opts: []orchestrator.Option{orchestrator.WithErrorHandler(orchestrator.ErrorHandlerFunc(func(err error) {
+			errs <- err
+		}))},
+		process: p,
+		processOpts: []orchestrator.ProcessOption{orchestrator.WithRetryPolicy(orchestrator.RetryPolicy{
+			MaxAttempts: 2,
+			Backoff:     orchestrator.Backoff{Initial: time.Millisecond},
+		})},
+		inputs: []orchestrator.Input{onceInput{id: "once-input"}},
+	})
+
+	var err error
+	select {
+	case err = <-errs:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for error")
+	}
+
+	var pe orchestrator.ProcessError
+	if !errors.As(err, &pe) {
+		t.Fatalf("expected ProcessError, received %#v", err)
+	}
+
+	expect := orchestrator.ProcessError{
+		Input:   "once-input",
+		Parent:  "once-input",
+		Process: p.ID(),
+		EventID: "1",
+		Attempt: 2,
+		Err:     pe.Err,
+	}
+
+	if expect != pe {
+		t.Errorf("expected %#v, received %#v", expect, pe)
+	}
+
+	if !errors.Is(err, oops) {
+		t.Errorf("expected %#v, received %#v", oops, pe.Err)
+	}
+}
+
+func TestOrchestrator_WithErrorDropPolicy(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		policy orchestrator.DropPolicy
+	}{
+		{"drop newest", orchestrator.DropNewest},
+		{"drop oldest", orchestrator.DropOldest},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			d := orchestrator.New(
+				orchestrator.WithErrorBuffer(1),
+				orchestrator.WithErrorDropPolicy(test.policy),
+				orchestrator.WithErrorHandler(orchestrator.ErrorHandlerFunc(func(error) {})),
+			)
+			defer d.Shutdown(context.Background())
+
+			p := newFlakyProcess(errors.New("oops"), 10)
+
+			err := d.AddProcess(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, id := range []string{"1", "2", "3"} {
+				err = d.Trigger(p.ID(), orchestrator.Event{ID: id})
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			deadline := time.Now().Add(time.Second)
+			for d.DroppedErrors() < 2 && time.Now().Before(deadline) {
+				time.Sleep(time.Millisecond)
+			}
+
+			if d.DroppedErrors() != 2 {
+				t.Errorf("expected 2 dropped errors, received %d", d.DroppedErrors())
+			}
+
+			if len(d.ErrorChan) != 1 {
+				t.Errorf("expected 1 buffered error, received %d", len(d.ErrorChan))
+			}
+		})
+	}
+}
+
+func TestOrchestrator_WithErrorDropPolicy_Block(t *testing.T) {
+	d := orchestrator.New(
+		orchestrator.WithErrorBuffer(0),
+		orchestrator.WithErrorDropPolicy(orchestrator.Block),
+		orchestrator.WithErrorHandler(orchestrator.ErrorHandlerFunc(func(error) {})),
+	)
+
+	p := newFlakyProcess(errors.New("oops"), 10)
+
+	err := d.AddProcess(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = d.Trigger(p.ID(), orchestrator.Event{ID: "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Give the process long enough to fail, so that its error is
+	// blocked waiting to be read
+	time.Sleep(time.Millisecond * 50)
+
+	select {
+	case err = <-d.ErrorChan:
+		if !errors.As(err, new(orchestrator.ProcessError)) {
+			t.Errorf("expected ProcessError, received %#v", err)
+		}
+
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for error")
+	}
+
+	if d.DroppedErrors() != 0 {
+		t.Errorf("expected 0 dropped errors, received %d", d.DroppedErrors())
+	}
+}
+
+func TestLogErrorHandler(t *testing.T) {
+	buf := new(bytes.Buffer)
+
+	h := orchestrator.LogErrorHandler{Logger: slog.New(slog.NewJSONHandler(buf, nil))}
+	h.HandleError(orchestrator.ProcessError{
+		Input:   "input",
+		Parent:  "parent",
+		Process: "process",
+		EventID: "1",
+		Attempt: 3,
+		Err:     errors.New("oops"),
+	})
+
+	var received map[string]any
+
+	err := json.Unmarshal(buf.Bytes(), &received)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for k, v := range map[string]any{
+		"level":    "ERROR",
+		"input":    "input",
+		"parent":   "parent",
+		"process":  "process",
+		"event_id": "1",
+		"attempt":  float64(3),
+	} {
+		if received[k] != v {
+			t.Errorf("%s: expected %v, received %v", k, v, received[k])
+		}
+	}
+}
+
+func TestProcessError_Error(t *testing.T) {
+	expect := `process "p" failed handling event "1" from "i" (attempt 2): oops`
+	received := orchestrator.ProcessError{Parent: "i", Process: "p", EventID: "1", Attempt: 2, Err: errors.New("oops")}.Error()
+
+	if expect != received {
+		t.Errorf("expected %q, received %q", expect, received)
+	}
+}
@@ -17,6 +17,11 @@ type Event struct {
 	// process, which can be useful for routing/ flow control in
 	// triggers
 	Trigger string `json:"trigger"`
+
+	// Metadata contains arbitrary values an Input, or the Orchestrator,
+	// wishes to pass on to a Process, such as the time a scheduled
+	// Event was scheduled for
+	Metadata map[string]string `json:"metadata,omitempty"`
 }
 
 // JSON returns the json representation for an event, in a way that our
@@ -14,8 +14,6 @@ var (
 )
 
 func main() {
-	orchestrator.ConcurrentProcessors = 4
-
 	rawInput, err := orchestrator.NewPostgresInput(orchestrator.InputConfig{
 		Name:             "raw_writes",
 		ConnectionString: raw,
@@ -42,7 +40,7 @@ func main() {
 		panic(err)
 	}
 
-	d := orchestrator.New()
+	d := orchestrator.New(orchestrator.WithGlobalConcurrency(4))
 
 	err = d.AddInput(context.Background(), rawInput)
 	if err != nil {
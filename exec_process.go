@@ -0,0 +1,242 @@
+package orchestrator
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Environment variables set for commands run by an ExecProcess
+const (
+	ExecEnvEvent     = "ORCHESTRATOR_EVENT"
+	ExecEnvID        = "ORCHESTRATOR_EVENT_ID"
+	ExecEnvLocation  = "ORCHESTRATOR_EVENT_LOCATION"
+	ExecEnvOperation = "ORCHESTRATOR_EVENT_OPERATION"
+	ExecEnvTrigger   = "ORCHESTRATOR_EVENT_TRIGGER"
+
+	// ExecEnvTraceParent and ExecEnvTraceState carry trace context, where
+	// the Event has any, as understood by OpenTelemetry tooling
+	ExecEnvTraceParent = "TRACEPARENT"
+	ExecEnvTraceState  = "TRACESTATE"
+)
+
+// execMaxLineLength is the longest line of output an ExecProcess captures,
+// with anything beyond it discarded
+const execMaxLineLength = 1 << 20
+
+func init() {
+	RegisterProcess("exec", NewExecProcess)
+}
+
+// ExecProcess is a Process which runs an external command for each Event.
+//
+// The Event is passed to the command as JSON on stdin (unless DisableStdin is
+// set), and as a set of environment variables (see ExecEnvEvent and friends).
+//
+// Each line the command writes to stdout or stderr is captured into the Logs
+// of the returned ProcessStatus, with lines over 1MiB truncated. A zero exit code gives a ProcessSuccess, and
+// anything else gives a ProcessFail, along with the error from the command.
+//
+// When the context passed to Run is cancelled, the command and any processes
+// it started are killed
+type ExecProcess struct {
+	name string
+
+	// Command is the command to run, either as an absolute path, or
+	// something which can be found on $PATH
+	Command string
+
+	// Args are passed to Command
+	Args []string
+
+	// Env contains environment variables, of the form KEY=value, to set
+	// on top of those set by the Orchestrator
+	Env []string
+
+	// Dir is the working directory for Command, defaulting to the
+	// current directory
+	Dir string
+
+	// DisableStdin stops the Event from being written to stdin
+	DisableStdin bool
+}
+
+// NewExecProcess accepts a ProcessConfig and returns an ExecProcess,
+// which implements the orchestrator.Process interface.
+//
+// The ExecutionContext of pc supports the following keys:
+//
+//	command: the command to run (required)
+//	args:    whitespace separated arguments to pass to command
+//	dir:     the working directory to run command in
+//	stdin:   set to "false" to stop the Event being written to stdin
+//	env.*:   environment variables, where env.FOO=bar sets FOO=bar
+func NewExecProcess(pc ProcessConfig) (Process, error) {
+	if pc.ID() == "" {
+		return nil, errors.New("exec process: name must be set")
+	}
+
+	e := ExecProcess{
+		name:         pc.ID(),
+		Command:      pc.ExecutionContext["command"],
+		Args:         strings.Fields(pc.ExecutionContext["args"]),
+		Dir:          pc.ExecutionContext["dir"],
+		DisableStdin: pc.ExecutionContext["stdin"] == "false",
+		Env:          make([]string, 0),
+	}
+
+	if e.Command == "" {
+		return nil, errors.New("exec process: command must be set")
+	}
+
+	for k, v := range pc.ExecutionContext {
+		if name, ok := strings.CutPrefix(k, "env."); ok {
+			e.Env = append(e.Env, name+"="+v)
+		}
+	}
+
+	// Map ordering is random, so sort to keep things predictable
+	sort.Strings(e.Env)
+
+	return e, nil
+}
+
+// ID returns the ID for this Process
+func (e ExecProcess) ID() string {
+	return e.name
+}
+
+// Run runs Command, returning once it exits
+func (e ExecProcess) Run(ctx context.Context, ev Event) (ps ProcessStatus, err error) {
+	ps.Name = e.name
+	ps.Status = ProcessUnstarted
+
+	j, err := ev.JSON()
+	if err != nil {
+		return
+	}
+
+	cmd := exec.CommandContext(ctx, e.Command, e.Args...)
+	cmd.Dir = e.Dir
+	cmd.Env = append(os.Environ(),
+		ExecEnvEvent+"="+j,
+		ExecEnvID+"="+ev.ID,
+		ExecEnvLocation+"="+ev.Location,
+		ExecEnvOperation+"="+ev.Operation.String(),
+		ExecEnvTrigger+"="+ev.Trigger,
+	)
+	if tp, ok := ev.Metadata[MetadataTraceParent]; ok {
+		cmd.Env = append(cmd.Env, ExecEnvTraceParent+"="+tp)
+	}
+
+	if ts, ok := ev.Metadata[MetadataTraceState]; ok {
+		cmd.Env = append(cmd.Env, ExecEnvTraceState+"="+ts)
+	}
+
+	cmd.Env = append(cmd.Env, e.Env...)
+	cmd.WaitDelay = time.Second * 5
+
+	setProcessGroup(cmd)
+
+	if !e.DisableStdin {
+		cmd.Stdin = strings.NewReader(j)
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return
+	}
+
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return
+	}
+
+	err = cmd.Start()
+	if err != nil {
+		return
+	}
+
+	ps.Status = ProcessUnknown
+
+	logs := new(execLogs)
+	wg := new(sync.WaitGroup)
+	wg.Add(2)
+
+	go logs.capture(wg, stdout)
+	go logs.capture(wg, stderr)
+
+	wg.Wait()
+
+	err = cmd.Wait()
+	ps.Logs = logs.lines
+
+	if err != nil {
+		ps.Status = ProcessFail
+		err = fmt.Errorf("exec process %q: %w", e.name, err)
+
+		return
+	}
+
+	ps.Status = ProcessSuccess
+
+	return
+}
+
+// execLogs collects lines of output from multiple streams
+type execLogs struct {
+	mutex sync.Mutex
+	lines []string
+}
+
+// capture reads r until it's closed, so that the command never blocks on
+// writing to a full pipe
+func (l *execLogs) capture(wg *sync.WaitGroup, r io.Reader) {
+	defer wg.Done()
+
+	br := bufio.NewReader(r)
+
+	for {
+		line, err := readExecLine(br)
+		if len(line) > 0 || err == nil {
+			l.mutex.Lock()
+			l.lines = append(l.lines, string(line))
+			l.mutex.Unlock()
+		}
+
+		if err != nil {
+			return
+		}
+	}
+}
+
+// readExecLine reads a line from r, without its line ending, keeping at most
+// execMaxLineLength bytes of it
+func readExecLine(r *bufio.Reader) (line []byte, err error) {
+	for {
+		var chunk []byte
+
+		chunk, err = r.ReadSlice('\n')
+		if n := execMaxLineLength - len(line); n > 0 {
+			line = append(line, chunk[:min(n, len(chunk))]...)
+		}
+
+		if !errors.Is(err, bufio.ErrBufferFull) {
+			break
+		}
+	}
+
+	line = bytes.TrimSuffix(line, []byte("\n"))
+	line = bytes.TrimSuffix(line, []byte("\r"))
+
+	return
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package orchestrator
+
+import (
+	"os/exec"
+)
+
+// setProcessGroup is a no-op on platforms without process groups, where
+// cancelling cmd only kills cmd itself
+func setProcessGroup(*exec.Cmd) {}
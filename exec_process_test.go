@@ -0,0 +1,150 @@
+package orchestrator_test
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dapper-data/dapper-orchestrator"
+)
+
+func TestNewExecProcess(t *testing.T) {
+	for _, test := range []struct {
+		name        string
+		ec          map[string]string
+		expect      orchestrator.ExecProcess
+		expectError bool
+	}{
+		{"command only", map[string]string{"command": "true"}, orchestrator.ExecProcess{Command: "true", Args: []string{}, Env: []string{}}, false},
+		{"everything set", map[string]string{"command": "./cleanse", "args": "--verbose  --dry-run", "dir": "/tmp", "stdin": "false", "env.B": "2", "env.A": "1"}, orchestrator.ExecProcess{Command: "./cleanse", Args: []string{"--verbose", "--dry-run"}, Env: []string{"A=1", "B=2"}, Dir: "/tmp", DisableStdin: true}, false},
+
+		// Error cases
+		{"missing command", map[string]string{}, orchestrator.ExecProcess{}, true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			p, err := orchestrator.NewExecProcess(orchestrator.ProcessConfig{Name: "exec", ExecutionContext: test.ec})
+			if err == nil && test.expectError {
+				t.Fatal("expected error, received none")
+			} else if err != nil && !test.expectError {
+				t.Fatalf("unexpected error %#v", err)
+			}
+
+			if test.expectError {
+				return
+			}
+
+			e := p.(orchestrator.ExecProcess)
+			if e.Command != test.expect.Command || !reflect.DeepEqual(e.Args, test.expect.Args) || !reflect.DeepEqual(e.Env, test.expect.Env) || e.Dir != test.expect.Dir || e.DisableStdin != test.expect.DisableStdin {
+				t.Errorf("expected\n%#v\nreceived\n%#v", test.expect, e)
+			}
+		})
+	}
+}
+
+func TestExecProcess_Run(t *testing.T) {
+	ev := orchestrator.Event{Location: "orders", Operation: orchestrator.OperationCreate, ID: "123", Trigger: "tests"}
+
+	for _, test := range []struct {
+		name         string
+		script       string
+		expectLogs   []string
+		expectStatus orchestrator.ProcessExitStatus
+		expectCode   int
+	}{
+		{"stdin", "cat", []string{`{"location":"orders","operation":"create","id":"123","trigger":"tests"}`}, orchestrator.ProcessSuccess, 0},
+		{"environment", `echo "$ORCHESTRATOR_EVENT_ID $ORCHESTRATOR_EVENT_LOCATION $ORCHESTRATOR_EVENT_OPERATION $ORCHESTRATOR_EVENT_TRIGGER $CUSTOM"`, []string{"123 orders create tests custom"}, orchestrator.ProcessSuccess, 0},
+		{"stderr", "echo oh no >&2; exit 3", []string{"oh no"}, orchestrator.ProcessFail, 3},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			p := orchestrator.ExecProcess{
+				Command: "sh",
+				Args:    []string{"-c", test.script},
+				Env:     []string{"CUSTOM=custom"},
+			}
+
+			ps, err := p.Run(context.Background(), ev)
+			if test.expectCode == 0 && err != nil {
+				t.Fatalf("unexpected error %#v", err)
+			}
+
+			if test.expectCode != 0 {
+				var ee *exec.ExitError
+				if !errors.As(err, &ee) {
+					t.Fatalf("expected *exec.ExitError, received %#v", err)
+				}
+
+				if ee.ExitCode() != test.expectCode {
+					t.Errorf("expected exit code %d, received %d", test.expectCode, ee.ExitCode())
+				}
+			}
+
+			if test.expectStatus != ps.Status {
+				t.Errorf("expected status %d, received %d", test.expectStatus, ps.Status)
+			}
+
+			if !reflect.DeepEqual(test.expectLogs, ps.Logs) {
+				t.Errorf("expected logs %q, received %q", test.expectLogs, ps.Logs)
+			}
+		})
+	}
+}
+
+func TestExecProcess_Run_LongLine(t *testing.T) {
+	// Three lines, the middle of which is 3MiB, and so longer than an
+	// ExecProcess captures
+	p := orchestrator.ExecProcess{
+		Command:      "sh",
+		Args:         []string{"-c", "echo before; head -c 3145728 /dev/zero | tr '\\0' x; echo; echo after"},
+		DisableStdin: true,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	ps, err := p.Run(ctx, orchestrator.Event{})
+	if err != nil {
+		t.Fatalf("unexpected error %#v", err)
+	}
+
+	if len(ps.Logs) != 3 {
+		t.Fatalf("expected 3 lines of logs, received %d", len(ps.Logs))
+	}
+
+	if ps.Logs[0] != "before" || ps.Logs[2] != "after" {
+		t.Errorf("expected logs either side of %q and %q, received %q and %q", "before", "after", ps.Logs[0], ps.Logs[2])
+	}
+
+	if expect := 1 << 20; len(ps.Logs[1]) != expect {
+		t.Errorf("expected long line to be truncated to %d bytes, received %d", expect, len(ps.Logs[1]))
+	}
+}
+
+func TestExecProcess_Run_Cancelled(t *testing.T) {
+	p := orchestrator.ExecProcess{
+		Command: "sh",
+		Args:    []string{"-c", "sleep 30 & sleep 30"},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+
+	start := time.Now()
+
+	ps, err := p.Run(ctx, orchestrator.Event{})
+	if err == nil {
+		t.Error("expected error, received none")
+	}
+
+	if ps.Status != orchestrator.ProcessFail {
+		t.Errorf("expected status %d, received %d", orchestrator.ProcessFail, ps.Status)
+	}
+
+	// If the background sleep were left running, it would hold stdout
+	// open, and Run would only return after cmd.WaitDelay
+	if time.Since(start) > time.Second {
+		t.Errorf("expected command to be killed promptly, took %s", time.Since(start))
+	}
+}
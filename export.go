@@ -0,0 +1,181 @@
+package orchestrator
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Colours used by WithStatusColours
+const (
+	colourIdle    = "#eeeeee"
+	colourRunning = "#9ecae1"
+	colourPaused  = "#fdd49e"
+	colourSuccess = "#a1d99b"
+	colourFailed  = "#fc9272"
+)
+
+// ExportDOT writes the Orchestrator's DAG to w in the Graphviz DOT language,
+// for rendering with something like:
+//
+//	dot -Tsvg dag.dot > dag.svg
+//
+// Inputs are drawn as ellipses, and Processes as boxes. Links with an
+// operations filter (see WithLinkOperations) are labelled with the operations
+// they allow through
+func (d Orchestrator) ExportDOT(w io.Writer, opts ...ExportOption) error {
+	o := newExportOptions(opts)
+	buf := new(bytes.Buffer)
+
+	buf.WriteString("digraph orchestrator {\n")
+	buf.WriteString("\trankdir=LR;\n")
+
+	for _, i := range d.Inputs() {
+		fmt.Fprintf(buf, "\t%s [shape=ellipse%s];\n", dotQuote(i.ID), dotFill(o, inputColour(i)))
+	}
+
+	for _, p := range d.Processes() {
+		fmt.Fprintf(buf, "\t%s [shape=box%s];\n", dotQuote(p.ID), dotFill(o, processColour(p)))
+	}
+
+	for _, l := range d.Links() {
+		fmt.Fprintf(buf, "\t%s -> %s", dotQuote(l.Parent), dotQuote(l.Child))
+
+		if label := linkLabel(l); label != "" {
+			fmt.Fprintf(buf, " [label=%s]", dotQuote(label))
+		}
+
+		buf.WriteString(";\n")
+	}
+
+	buf.WriteString("}\n")
+
+	_, err := buf.WriteTo(w)
+
+	return err
+}
+
+// ExportMermaid writes the Orchestrator's DAG to w as a Mermaid flowchart,
+// which can be embedded directly into markdown on the likes of GitHub.
+//
+// Inputs are drawn as stadiums, and Processes as rectangles. Links with an
+// operations filter (see WithLinkOperations) are labelled with the operations
+// they allow through
+func (d Orchestrator) ExportMermaid(w io.Writer, opts ...ExportOption) error {
+	o := newExportOptions(opts)
+	buf := new(bytes.Buffer)
+
+	// IDs can contain anything, which Mermaid may not like, and so
+	// nodes are given generated IDs, and labelled with the real thing
+	nodes := make(map[string]string)
+	colours := make(map[string][]string)
+
+	node := func(id, colour string) string {
+		n := fmt.Sprintf("n%d", len(nodes))
+		nodes[id] = n
+
+		if o.statusColours {
+			colours[colour] = append(colours[colour], n)
+		}
+
+		return n
+	}
+
+	buf.WriteString("flowchart LR\n")
+
+	for _, i := range d.Inputs() {
+		fmt.Fprintf(buf, "\t%s([%s])\n", node(i.ID, inputColour(i)), mermaidQuote(i.ID))
+	}
+
+	for _, p := range d.Processes() {
+		fmt.Fprintf(buf, "\t%s[%s]\n", node(p.ID, processColour(p)), mermaidQuote(p.ID))
+	}
+
+	for _, l := range d.Links() {
+		arrow := "-->"
+		if label := linkLabel(l); label != "" {
+			arrow = fmt.Sprintf("-- %s -->", mermaidQuote(label))
+		}
+
+		fmt.Fprintf(buf, "\t%s %s %s\n", nodes[l.Parent], arrow, nodes[l.Child])
+	}
+
+	for _, colour := range []string{colourIdle, colourRunning, colourPaused, colourSuccess, colourFailed} {
+		if len(colours[colour]) == 0 {
+			continue
+		}
+
+		class := "c" + strings.TrimPrefix(colour, "#")
+
+		fmt.Fprintf(buf, "\tclassDef %s fill:%s\n", class, colour)
+		fmt.Fprintf(buf, "\tclass %s %s\n", strings.Join(colours[colour], ","), class)
+	}
+
+	_, err := buf.WriteTo(w)
+
+	return err
+}
+
+// inputColour returns the colour representing the state of an Input
+func inputColour(i InputInfo) string {
+	switch {
+	case i.Paused, i.State == InputRestarting:
+		return colourPaused
+
+	case i.State == InputFailed:
+		return colourFailed
+
+	case i.State == InputRunning:
+		return colourRunning
+	}
+
+	return colourIdle
+}
+
+// processColour returns the colour representing the state of a Process,
+// preferring whether it's running over how it last ran
+func processColour(p ProcessInfo) string {
+	switch {
+	case p.InFlight > 0:
+		return colourRunning
+
+	case p.LastStatus == nil:
+		return colourIdle
+
+	case *p.LastStatus == ProcessSuccess:
+		return colourSuccess
+	}
+
+	return colourFailed
+}
+
+// linkLabel returns the operations a link allows, or an empty string where
+// it allows everything
+func linkLabel(l LinkInfo) string {
+	ops := make([]string, len(l.Operations))
+	for i, op := range l.Operations {
+		ops[i] = op.String()
+	}
+
+	return strings.Join(ops, ", ")
+}
+
+func dotFill(o *exportOptions, colour string) string {
+	if !o.statusColours {
+		return ""
+	}
+
+	return fmt.Sprintf(", style=filled, fillcolor=%s", dotQuote(colour))
+}
+
+// dotQuote returns s as a DOT quoted string
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+// mermaidQuote returns s as a Mermaid quoted string, using Mermaid's entity
+// codes for anything which would otherwise end the string
+func mermaidQuote(s string) string {
+	return `"` + strings.NewReplacer(`"`, "#quot;", "\n", " ").Replace(s) + `"`
+}
@@ -0,0 +1,145 @@
+package orchestrator_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dapper-data/dapper-orchestrator"
+)
+
+func setupExportTest(t *testing.T) (*orchestrator.Orchestrator, *recordingProcess) {
+	t.Helper()
+
+	cleanse := newRecordingProcess("cleanse", orchestrator.ProcessSuccess)
+	report := newRecordingProcess(`report "daily"`, orchestrator.ProcessFail)
+
+	d := setupOrchestrator(t, testDAG{
+		process:  cleanse,
+		linkOpts: []orchestrator.LinkOption{orchestrator.WithLinkOperations(orchestrator.OperationCreate, orchestrator.OperationUpdate)},
+		inputs:   []orchestrator.Input{newSequence("raw", 0)},
+	})
+
+	err := d.AddProcess(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = d.AddProcessLink(cleanse, report)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	eventually(t, "input to start", func() bool {
+		return d.Inputs()[0].State == orchestrator.InputRunning
+	})
+
+	return d, cleanse
+}
+
+func TestOrchestrator_ExportDOT(t *testing.T) {
+	d, _ := setupExportTest(t)
+
+	expect := `digraph orchestrator {
+	rankdir=LR;
+	"raw" [shape=ellipse];
+	"cleanse" [shape=box];
+	"report \"daily\"" [shape=box];
+	"cleanse" -> "report \"daily\"";
+	"raw" -> "cleanse" [label="create, update"];
+}
+`
+
+	buf := new(strings.Builder)
+
+	err := d.ExportDOT(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expect != buf.String() {
+		t.Errorf("expected\n%s\nreceived\n%s", expect, buf.String())
+	}
+}
+
+func TestOrchestrator_ExportMermaid(t *testing.T) {
+	d, _ := setupExportTest(t)
+
+	expect := `flowchart LR
+	n0(["raw"])
+	n1["cleanse"]
+	n2["report #quot;daily#quot;"]
+	n1 --> n2
+	n0 -- "create, update" --> n1
+`
+
+	buf := new(strings.Builder)
+
+	err := d.ExportMermaid(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expect != buf.String() {
+		t.Errorf("expected\n%s\nreceived\n%s", expect, buf.String())
+	}
+}
+
+func TestOrchestrator_Export_WithStatusColours(t *testing.T) {
+	d, cleanse := setupExportTest(t)
+
+	err := d.Trigger("cleanse", orchestrator.Event{ID: "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cleanse.next(t)
+
+	eventually(t, "runs to complete", func() bool {
+		p := d.Processes()
+
+		return p[0].LastStatus != nil && p[1].LastStatus != nil
+	})
+
+	err = d.PauseInput("raw")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("dot", func(t *testing.T) {
+		buf := new(strings.Builder)
+
+		err := d.ExportDOT(buf, orchestrator.WithStatusColours())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, expect := range []string{
+			`"raw" [shape=ellipse, style=filled, fillcolor="#fdd49e"];`,
+			`"cleanse" [shape=box, style=filled, fillcolor="#a1d99b"];`,
+			`"report \"daily\"" [shape=box, style=filled, fillcolor="#fc9272"];`,
+		} {
+			if !strings.Contains(buf.String(), expect) {
+				t.Errorf("expected %q in\n%s", expect, buf.String())
+			}
+		}
+	})
+
+	t.Run("mermaid", func(t *testing.T) {
+		buf := new(strings.Builder)
+
+		err := d.ExportMermaid(buf, orchestrator.WithStatusColours())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, expect := range []string{
+			"\tclassDef cfdd49e fill:#fdd49e\n\tclass n0 cfdd49e\n",
+			"\tclassDef ca1d99b fill:#a1d99b\n\tclass n1 ca1d99b\n",
+			"\tclassDef cfc9272 fill:#fc9272\n\tclass n2 cfc9272\n",
+		} {
+			if !strings.Contains(buf.String(), expect) {
+				t.Errorf("expected %q in\n%s", expect, buf.String())
+			}
+		}
+	})
+}
@@ -0,0 +1,21 @@
+package orchestrator
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// IDGenerator returns a new, unique, ID each time it is called, and is
+// used to give each Dispatch an ID (see WithIDGenerator)
+type IDGenerator func() string
+
+// RandomID is the IDGenerator used by default, and returns 16 random bytes,
+// hex encoded
+func RandomID() string {
+	b := make([]byte, 16)
+
+	// crypto/rand.Read never returns an error on supported platforms
+	rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
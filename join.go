@@ -0,0 +1,236 @@
+package orchestrator
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultJoinWindow is the Window used by JoinPolicies which don't set one
+const DefaultJoinWindow = time.Hour
+
+// ErrInvalidQuorum is returned when adding a Process whose JoinPolicy uses
+// JoinQuorum with a Quorum of less than one
+var ErrInvalidQuorum = errors.New("join policy: quorum must be at least 1")
+
+// JoinMode determines when a Process with multiple parents is run
+type JoinMode uint8
+
+// Supported set of JoinModes
+const (
+	// JoinAny runs a Process whenever any of its parents trigger it
+	JoinAny JoinMode = iota
+
+	// JoinAll runs a Process once every one of its parents has
+	// triggered it for the same correlation key
+	JoinAll
+
+	// JoinQuorum runs a Process once a set number of its parents have
+	// triggered it for the same correlation key. Where the Process has
+	// fewer parents than Quorum, every parent must trigger it
+	JoinQuorum
+)
+
+// JoinPolicy configures how a Process with multiple parents, be they Inputs
+// or other Processes, is triggered.
+//
+// For instance, a reconciliation process which should only run when both an
+// orders input and a payments input have seen the same ID would use:
+//
+//	orchestrator.JoinPolicy{
+//	   Mode:   orchestrator.JoinAll,
+//	   Window: time.Minute,
+//	}
+//
+// When a join completes, the Process is run with the Event which completed it
+type JoinPolicy struct {
+	Mode JoinMode
+
+	// Quorum is the number of distinct parents which must trigger a
+	// Process when Mode is JoinQuorum
+	Quorum int
+
+	// Window is how long to wait for the rest of a join after the first
+	// parent triggers it, as measured by the Orchestrator's Clock. Joins
+	// which don't complete in time are dropped, and a JoinExpiredError is
+	// sent to the ErrorChan.
+	//
+	// A Window of zero or less uses DefaultJoinWindow, so that incomplete
+	// joins can't build up forever
+	Window time.Duration
+
+	// Key returns the correlation key for an Event. When nil, Event.ID
+	// is used
+	Key func(Event) string
+}
+
+func (p JoinPolicy) validate() error {
+	if p.Mode == JoinQuorum && p.Quorum < 1 {
+		return ErrInvalidQuorum
+	}
+
+	return nil
+}
+
+func (p JoinPolicy) window() time.Duration {
+	if p.Window <= 0 {
+		return DefaultJoinWindow
+	}
+
+	return p.Window
+}
+
+func (p JoinPolicy) key(e Event) string {
+	if p.Key == nil {
+		return e.ID
+	}
+
+	return p.Key(e)
+}
+
+// JoinExpiredError is sent to the ErrorChan when a join does not complete
+// within its JoinPolicy's Window
+type JoinExpiredError struct {
+	// Process is the ID of the Process waiting on the join
+	Process string
+
+	// Key is the correlation key of the join
+	Key string
+
+	// Parents contains the IDs of the parents which did trigger the
+	// Process before the join expired
+	Parents []string
+}
+
+// Error returns a descriptive error message
+func (e JoinExpiredError) Error() string {
+	return fmt.Sprintf("join for %q on key %q expired, having only been triggered by %q", e.Process, e.Key, e.Parents)
+}
+
+// joiner tracks the parents which have triggered a Process for each
+// correlation key, in order to determine when that Process should run
+type joiner struct {
+	policy   JoinPolicy
+	clock    Clock
+	onExpire func(key string, parents []string)
+
+	mutex   sync.Mutex
+	pending map[string]*pendingJoin
+}
+
+type pendingJoin struct {
+	parents map[string]struct{}
+
+	// done is closed when the join completes, to stop waiting for it
+	// to expire
+	done chan struct{}
+}
+
+func newJoiner(policy JoinPolicy, clock Clock, onExpire func(string, []string)) *joiner {
+	return &joiner{
+		policy:   policy,
+		clock:    clock,
+		onExpire: onExpire,
+		pending:  make(map[string]*pendingJoin),
+	}
+}
+
+// arrive records parent as having triggered the join for event, returning
+// true when the join is complete and so the Process should run.
+//
+// parents is the number of parents the Process currently has
+func (j *joiner) arrive(parent string, event Event, parents int) bool {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	required := 1
+
+	switch j.policy.Mode {
+	case JoinAny:
+		return true
+
+	case JoinAll:
+		required = parents
+
+	case JoinQuorum:
+		// Joins must still be able to complete where there are
+		// fewer parents than Quorum, such as after RemoveProcess
+		required = min(j.policy.Quorum, parents)
+	}
+
+	key := j.policy.key(event)
+
+	p, ok := j.pending[key]
+	if !ok {
+		p = &pendingJoin{
+			parents: make(map[string]struct{}),
+			done:    make(chan struct{}),
+		}
+
+		go j.wait(key, p, j.policy.window())
+
+		j.pending[key] = p
+	}
+
+	p.parents[parent] = struct{}{}
+	if len(p.parents) < required {
+		return false
+	}
+
+	close(p.done)
+	delete(j.pending, key)
+
+	return true
+}
+
+// mode returns the JoinMode of the joiner's policy
+func (j *joiner) mode() JoinMode {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	return j.policy.Mode
+}
+
+// setPolicy replaces the joiner's policy, keeping any pending joins. Pending
+// joins keep the Window they started with
+func (j *joiner) setPolicy(policy JoinPolicy) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	j.policy = policy
+}
+
+// wait expires the join p once window has elapsed, unless it completes first
+func (j *joiner) wait(key string, p *pendingJoin, window time.Duration) {
+	select {
+	case <-j.clock.After(window):
+		j.expire(key, p)
+
+	case <-p.done:
+	}
+}
+
+func (j *joiner) expire(key string, p *pendingJoin) {
+	j.mutex.Lock()
+
+	if j.pending[key] != p {
+		// This join has already completed
+		j.mutex.Unlock()
+
+		return
+	}
+
+	delete(j.pending, key)
+
+	parents := make([]string, 0, len(p.parents))
+	for parent := range p.parents {
+		parents = append(parents, parent)
+	}
+
+	j.mutex.Unlock()
+
+	sort.Strings(parents)
+	j.onExpire(key, parents)
+}
@@ -0,0 +1,154 @@
+package orchestrator_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dapper-data/dapper-orchestrator"
+)
+
+func setupJoinTest(t *testing.T, policy orchestrator.JoinPolicy, inputs ...orchestrator.Input) (*orchestrator.Orchestrator, *recordingProcess) {
+	t.Helper()
+
+	p := newRecordingProcess("reconciliation", orchestrator.ProcessSuccess)
+	d := setupOrchestrator(t, testDAG{
+		process:     p,
+		processOpts: []orchestrator.ProcessOption{orchestrator.WithJoinPolicy(policy)},
+		inputs:      inputs,
+	})
+
+	return d, p
+}
+
+func TestJoinPolicy_JoinAny(t *testing.T) {
+	_, p := setupJoinTest(t, orchestrator.JoinPolicy{}, onceInput{id: "orders"}, onceInput{id: "payments"})
+
+	p.next(t)
+	p.next(t)
+}
+
+func TestJoinPolicy_JoinAll(t *testing.T) {
+	_, p := setupJoinTest(t, orchestrator.JoinPolicy{Mode: orchestrator.JoinAll}, onceInput{id: "orders"}, onceInput{id: "payments"})
+
+	ev := p.next(t)
+	if ev.ID != "1" {
+		t.Errorf("expected event ID %q, received %q", "1", ev.ID)
+	}
+
+	p.expectNoRun(t)
+}
+
+func TestJoinPolicy_JoinQuorum(t *testing.T) {
+	_, p := setupJoinTest(t, orchestrator.JoinPolicy{Mode: orchestrator.JoinQuorum, Quorum: 2}, onceInput{id: "orders"}, onceInput{id: "payments"}, onceInput{id: "refunds"})
+
+	p.next(t)
+	p.expectNoRun(t)
+}
+
+func TestJoinPolicy_JoinQuorum_AboveParents(t *testing.T) {
+	_, p := setupJoinTest(t, orchestrator.JoinPolicy{Mode: orchestrator.JoinQuorum, Quorum: 3}, onceInput{id: "orders"}, onceInput{id: "payments"})
+
+	p.next(t)
+	p.expectNoRun(t)
+}
+
+func TestJoinPolicy_InvalidQuorum(t *testing.T) {
+	for _, quorum := range []int{0, -1} {
+		t.Run("", func(t *testing.T) {
+			d := setupOrchestrator(t, testDAG{})
+			p := newRecordingProcess("reconciliation", orchestrator.ProcessSuccess)
+
+			err := d.AddProcess(p, orchestrator.WithJoinPolicy(orchestrator.JoinPolicy{Mode: orchestrator.JoinQuorum, Quorum: quorum}))
+			if !errors.Is(err, orchestrator.ErrInvalidQuorum) {
+				t.Errorf("expected %#v, received %#v", orchestrator.ErrInvalidQuorum, err)
+			}
+
+			if len(d.Processes()) != 0 {
+				t.Errorf("expected no processes, received %#v", d.Processes())
+			}
+
+			err = d.AddProcess(p)
+			if err != nil {
+				t.Errorf("unexpected error %#v", err)
+			}
+		})
+	}
+}
+
+func TestJoinPolicy_Window(t *testing.T) {
+	d, p := setupJoinTest(t, orchestrator.JoinPolicy{Mode: orchestrator.JoinAll, Window: time.Millisecond * 20}, onceInput{id: "orders"})
+
+	// Add a second parent which never triggers
+	silent := newRecordingProcess("silent", orchestrator.ProcessSuccess)
+
+	err := d.AddProcess(silent)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = d.AddProcessLink(silent, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case err = <-d.ErrorChan:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for join to expire")
+	}
+
+	var jee orchestrator.JoinExpiredError
+	if !errors.As(err, &jee) {
+		t.Fatalf("expected orchestrator.JoinExpiredError, received %#v", err)
+	}
+
+	expect := orchestrator.JoinExpiredError{Process: "reconciliation", Key: "1", Parents: []string{"orders"}}
+	if !reflect.DeepEqual(expect, jee) {
+		t.Errorf("expected\n%#v\nreceived\n%#v", expect, jee)
+	}
+
+	p.expectNoRun(t)
+}
+
+func TestJoinPolicy_Window_Clock(t *testing.T) {
+	// fakeClock never waits, and so joins expire straight away, however
+	// long their Window, including the default Window
+	for _, window := range []time.Duration{time.Hour, 0} {
+		t.Run(window.String(), func(t *testing.T) {
+			p := newRecordingProcess("reconciliation", orchestrator.ProcessSuccess)
+
+			// The silent input never sends anything, and so the
+			// join can't complete
+			d := setupOrchestrator(t, testDAG{
+				opts:        []orchestrator.Option{orchestrator.WithClock(fakeClock{now: time.Now()})},
+				process:     p,
+				processOpts: []orchestrator.ProcessOption{orchestrator.WithJoinPolicy(orchestrator.JoinPolicy{Mode: orchestrator.JoinAll, Window: window})},
+				inputs:      []orchestrator.Input{newSequence("silent", 0), onceInput{id: "orders"}},
+			})
+
+			var err error
+			select {
+			case err = <-d.ErrorChan:
+			case <-time.After(time.Second):
+				t.Fatal("timed out waiting for join to expire")
+			}
+
+			if !errors.As(err, new(orchestrator.JoinExpiredError)) {
+				t.Errorf("expected orchestrator.JoinExpiredError, received %#v", err)
+			}
+
+			p.expectNoRun(t)
+		})
+	}
+}
+
+func TestJoinExpiredError_Error(t *testing.T) {
+	expect := `join for "reconciliation" on key "1" expired, having only been triggered by ["orders"]`
+	err := orchestrator.JoinExpiredError{Process: "reconciliation", Key: "1", Parents: []string{"orders"}}
+
+	if expect != err.Error() {
+		t.Errorf("expected\n%s\nreceived\n%s", expect, err.Error())
+	}
+}
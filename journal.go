@@ -0,0 +1,442 @@
+package orchestrator
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultJournalSegmentSize is the size, in bytes, a FileJournal segment
+// grows to before a new one is started
+const DefaultJournalSegmentSize = 16 << 20
+
+// Journal is a write-ahead log of Dispatches, allowing for Events which
+// were in-flight when an Orchestrator stopped, such as after a crash, to be
+// replayed when it starts again (see Orchestrator.Replay).
+//
+// Every Dispatch is appended to the Journal before its Process runs, and is
+// acknowledged once that Process succeeds (or once it has been accepted by a
+// DeadLetterSink), so that Pending returns every Dispatch which has yet to be
+// handled
+type Journal interface {
+	Append(Dispatch) (uint64, error)
+	Ack(uint64) error
+	Pending() ([]JournalEntry, error)
+}
+
+// JournalEntry is a Dispatch which has been appended to a Journal, along
+// with the ID the Journal gave it
+type JournalEntry struct {
+	ID uint64
+	Dispatch
+}
+
+// JournalError is sent to the ErrorChan when a Journal fails to append or
+// acknowledge a Dispatch. A Dispatch which could not be appended still runs,
+// but will not be replayed should the Orchestrator stop before it completes
+type JournalError struct {
+	Op       string
+	Dispatch Dispatch
+	Err      error
+}
+
+// Error returns a descriptive error message
+func (e JournalError) Error() string {
+	return fmt.Sprintf("journal %s for dispatch from %q to %q failed: %s", e.Op, e.Dispatch.Parent, e.Dispatch.Process, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e JournalError) Unwrap() error {
+	return e.Err
+}
+
+// SetJournal sets the Journal which Dispatches are written to before being
+// run. It should be called before any Inputs are added, so that no Events are
+// missed.
+//
+// Passing nil disables journalling, which is the default
+func (d Orchestrator) SetJournal(j Journal) {
+	if j == nil {
+		d.journal.Store(nil)
+
+		return
+	}
+
+	d.journal.Store(&j)
+}
+
+// Replay runs every Dispatch which the Journal holds as pending, such as those
+// which were in-flight when a previous Orchestrator stopped. It should be called
+// once every Process and link has been added.
+//
+// Replayed Dispatches run in the background, and are acknowledged as normal. The
+// number of Dispatches replayed is returned, along with an error for any which
+// could not be, such as where their Process no longer exists; these are left
+// pending
+func (d Orchestrator) Replay() (n int, err error) {
+	j := d.journal.Load()
+	if j == nil {
+		return
+	}
+
+	entries, err := (*j).Pending()
+	if err != nil {
+		return
+	}
+
+	errs := make([]error, 0)
+	for _, entry := range entries {
+		if _, ok := d.processes.Load(entry.Process); !ok {
+			errs = append(errs, UnknownProcessError{
+				input:   entry.Parent,
+				process: entry.Process,
+			})
+
+			continue
+		}
+
+		if !d.run(entry.Dispatch, entry.ID, false, nil) {
+			errs = append(errs, ErrOrchestratorClosed)
+
+			break
+		}
+
+		n++
+	}
+
+	return n, errors.Join(errs...)
+}
+
+// journalAppend appends dispatch to the Journal, if one is set, returning
+// the ID of the resulting entry, or zero where it wasn't journalled
+func (d Orchestrator) journalAppend(dispatch Dispatch) uint64 {
+	j := d.journal.Load()
+	if j == nil {
+		return 0
+	}
+
+	id, err := (*j).Append(dispatch)
+	if err != nil {
+		d.reportError(JournalError{Op: "append", Dispatch: dispatch, Err: err})
+
+		return 0
+	}
+
+	return id
+}
+
+// journalAck acknowledges a journalled Dispatch
+func (d Orchestrator) journalAck(dispatch Dispatch, id uint64) {
+	j := d.journal.Load()
+	if j == nil || id == 0 {
+		return
+	}
+
+	err := (*j).Ack(id)
+	if err != nil {
+		d.reportError(JournalError{Op: "ack", Dispatch: dispatch, Err: err})
+	}
+}
+
+// FileJournal is a Journal which stores Dispatches on local disk, as newline
+// delimited JSON, in a directory of numbered segment files.
+//
+// Once every Dispatch in the oldest segments has been acknowledged, those
+// segments are removed, keeping the journal from growing forever
+type FileJournal struct {
+	// SegmentSize is the size, in bytes, a segment grows to before a new
+	// one is started
+	SegmentSize int64
+
+	mutex    sync.Mutex
+	dir      string
+	next     uint64
+	pending  map[uint64]journalPending
+	segments []*journalSegment
+	active   *os.File
+	size     int64
+}
+
+type journalSegment struct {
+	n           int
+	outstanding int
+}
+
+type journalPending struct {
+	segment *journalSegment
+	entry   JournalEntry
+}
+
+// journalRecord is a single line of a FileJournal segment
+type journalRecord struct {
+	Op       string    `json:"op"`
+	ID       uint64    `json:"id"`
+	Dispatch *Dispatch `json:"dispatch,omitempty"`
+}
+
+// OpenFileJournal opens the FileJournal in dir, creating it if needed, and
+// reading any pending Dispatches from a previous run
+func OpenFileJournal(dir string) (j *FileJournal, err error) {
+	err = os.MkdirAll(dir, 0o700)
+	if err != nil {
+		return
+	}
+
+	j = &FileJournal{
+		SegmentSize: DefaultJournalSegmentSize,
+		dir:         dir,
+		pending:     make(map[uint64]journalPending),
+		segments:    make([]*journalSegment, 0),
+	}
+
+	names, err := filepath.Glob(filepath.Join(dir, "*.journal"))
+	if err != nil {
+		return
+	}
+
+	numbers := make([]int, 0, len(names))
+	for _, name := range names {
+		var n int
+
+		n, err = strconv.Atoi(strings.TrimSuffix(filepath.Base(name), ".journal"))
+		if err != nil {
+			return nil, fmt.Errorf("journal: unexpected file %s", name)
+		}
+
+		numbers = append(numbers, n)
+	}
+
+	sort.Ints(numbers)
+
+	for _, n := range numbers {
+		err = j.load(n)
+		if err != nil {
+			return
+		}
+	}
+
+	next := 1
+	if len(numbers) > 0 {
+		next = numbers[len(numbers)-1] + 1
+	}
+
+	err = j.rotate(next)
+	if err != nil {
+		return
+	}
+
+	return j, j.compact()
+}
+
+// load reads segment n, replaying its records into j
+func (j *FileJournal) load(n int) (err error) {
+	f, err := os.Open(j.segmentPath(n))
+	if err != nil {
+		return
+	}
+
+	defer f.Close()
+
+	segment := &journalSegment{n: n}
+	j.segments = append(j.segments, segment)
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), 64<<20)
+
+	var corrupt error
+
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		// Only the final line of a segment may be corrupt, such as
+		// where a write was interrupted by a crash
+		if corrupt != nil {
+			return corrupt
+		}
+
+		var r journalRecord
+
+		err = json.Unmarshal(scanner.Bytes(), &r)
+		if err != nil {
+			corrupt = fmt.Errorf("journal: corrupt segment %s: %w", f.Name(), err)
+
+			continue
+		}
+
+		if r.ID >= j.next {
+			j.next = r.ID
+		}
+
+		switch r.Op {
+		case "append":
+			if r.Dispatch == nil {
+				return fmt.Errorf("journal: append %d in %s has no dispatch", r.ID, f.Name())
+			}
+
+			segment.outstanding++
+			j.pending[r.ID] = journalPending{
+				segment: segment,
+				entry:   JournalEntry{ID: r.ID, Dispatch: *r.Dispatch},
+			}
+
+		case "ack":
+			if p, ok := j.pending[r.ID]; ok {
+				p.segment.outstanding--
+				delete(j.pending, r.ID)
+			}
+		}
+	}
+
+	return scanner.Err()
+}
+
+// Append writes a Dispatch to the journal, syncing it to disk before
+// returning
+func (j *FileJournal) Append(d Dispatch) (id uint64, err error) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	j.next++
+	id = j.next
+
+	err = j.write(journalRecord{Op: "append", ID: id, Dispatch: &d})
+	if err != nil {
+		return 0, err
+	}
+
+	segment := j.segments[len(j.segments)-1]
+	segment.outstanding++
+
+	j.pending[id] = journalPending{
+		segment: segment,
+		entry:   JournalEntry{ID: id, Dispatch: d},
+	}
+
+	return
+}
+
+// Ack marks a Dispatch as handled, so that it is no longer pending
+func (j *FileJournal) Ack(id uint64) (err error) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	p, ok := j.pending[id]
+	if !ok {
+		return
+	}
+
+	err = j.write(journalRecord{Op: "ack", ID: id})
+	if err != nil {
+		return
+	}
+
+	p.segment.outstanding--
+	delete(j.pending, id)
+
+	return j.compact()
+}
+
+// Pending returns every Dispatch which has not been acknowledged, in the
+// order they were appended
+func (j *FileJournal) Pending() (entries []JournalEntry, err error) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	entries = make([]JournalEntry, 0, len(j.pending))
+	for _, p := range j.pending {
+		entries = append(entries, p.entry)
+	}
+
+	slices.SortFunc(entries, func(a, b JournalEntry) int {
+		switch {
+		case a.ID < b.ID:
+			return -1
+		case a.ID > b.ID:
+			return 1
+		}
+
+		return 0
+	})
+
+	return
+}
+
+// Close closes the active segment
+func (j *FileJournal) Close() error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	return j.active.Close()
+}
+
+func (j *FileJournal) write(r journalRecord) (err error) {
+	if j.SegmentSize > 0 && j.size >= j.SegmentSize {
+		err = j.rotate(j.segments[len(j.segments)-1].n + 1)
+		if err != nil {
+			return
+		}
+	}
+
+	b, err := json.Marshal(r)
+	if err != nil {
+		return
+	}
+
+	n, err := j.active.Write(append(b, '\n'))
+	j.size += int64(n)
+
+	if err != nil {
+		return
+	}
+
+	return j.active.Sync()
+}
+
+// rotate starts segment n, closing the current active segment
+func (j *FileJournal) rotate(n int) (err error) {
+	f, err := os.OpenFile(j.segmentPath(n), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return
+	}
+
+	if j.active != nil {
+		j.active.Close()
+	}
+
+	j.active = f
+	j.size = 0
+	j.segments = append(j.segments, &journalSegment{n: n})
+
+	return
+}
+
+// compact removes the oldest segments, for as long as they have no
+// outstanding Dispatches. Segments are only ever removed oldest first,
+// so that acknowledgements are never lost while the Dispatches they
+// refer to remain on disk
+func (j *FileJournal) compact() (err error) {
+	for len(j.segments) > 1 && j.segments[0].outstanding == 0 {
+		err = os.Remove(j.segmentPath(j.segments[0].n))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return
+		}
+
+		j.segments = j.segments[1:]
+	}
+
+	return nil
+}
+
+func (j *FileJournal) segmentPath(n int) string {
+	return filepath.Join(j.dir, fmt.Sprintf("%020d.journal", n))
+}
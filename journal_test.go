@@ -0,0 +1,228 @@
+package orchestrator_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dapper-data/dapper-orchestrator"
+)
+
+func TestFileJournal(t *testing.T) {
+	dir := t.TempDir()
+
+	j, err := orchestrator.OpenFileJournal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dispatches := []orchestrator.Dispatch{
+		{Parent: "input", Process: "a", Event: orchestrator.Event{ID: "1", Operation: orchestrator.OperationCreate}},
+		{Parent: "input", Process: "b", Event: orchestrator.Event{ID: "1"}},
+		{Parent: "a", Process: "c", Event: orchestrator.Event{ID: "2", Metadata: map[string]string{"k": "v"}}},
+	}
+
+	ids := make([]uint64, 0)
+	for _, d := range dispatches {
+		var id uint64
+
+		id, err = j.Append(d)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ids = append(ids, id)
+	}
+
+	err = j.Ack(ids[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = j.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	j, err = orchestrator.OpenFileJournal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer j.Close()
+
+	expect := []orchestrator.JournalEntry{
+		{ID: ids[0], Dispatch: dispatches[0]},
+		{ID: ids[2], Dispatch: dispatches[2]},
+	}
+
+	received, err := j.Pending()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(expect, received) {
+		t.Errorf("expected %#v, received %#v", expect, received)
+	}
+
+	id, err := j.Append(dispatches[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if id <= ids[2] {
+		t.Errorf("expected id greater than %d, received %d", ids[2], id)
+	}
+}
+
+func TestFileJournal_Compaction(t *testing.T) {
+	dir := t.TempDir()
+
+	j, err := orchestrator.OpenFileJournal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer j.Close()
+
+	// Start a new segment on every write
+	j.SegmentSize = 1
+
+	for i := 0; i < 10; i++ {
+		id, err := j.Append(orchestrator.Dispatch{Parent: "input", Process: "a"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = j.Ack(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	segments, err := filepath.Glob(filepath.Join(dir, "*.journal"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(segments) != 1 {
+		t.Errorf("expected 1 segment, received %d", len(segments))
+	}
+}
+
+func TestOrchestrator_Replay(t *testing.T) {
+	dir := t.TempDir()
+	oops := errors.New("oops")
+
+	j, err := orchestrator.OpenFileJournal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first orchestrator fails to process the event, and has no
+	// DeadLetterSink, leaving the event pending in the journal
+	p := &failOnceProcess{err: oops, events: make(chan orchestrator.Event, 10)}
+
+	d := setupOrchestrator(t, testDAG{
+		before:  func(d *orchestrator.Orchestrator) { d.SetJournal(j) },
+		process: p,
+		inputs:  []orchestrator.Input{onceInput{id: "once-input"}},
+	})
+
+	select {
+	case <-d.ErrorChan:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for error")
+	}
+
+	_, err = d.Shutdown(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = j.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The second orchestrator replays it
+	j, err = orchestrator.OpenFileJournal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer j.Close()
+
+	p2 := newRecordingProcess(p.ID(), orchestrator.ProcessSuccess)
+	child := newRecordingProcess("child", orchestrator.ProcessSuccess)
+
+	d = setupOrchestrator(t, testDAG{
+		before:   func(d *orchestrator.Orchestrator) { d.SetJournal(j) },
+		process:  p2,
+		children: []orchestrator.Process{child},
+	})
+
+	n, err := d.Replay()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 1 {
+		t.Errorf("expected 1 replayed dispatch, received %d", n)
+	}
+
+	expect := orchestrator.Event{ID: "1", Trigger: "once-input"}
+
+	ev := p2.next(t)
+	if !reflect.DeepEqual(expect, ev) {
+		t.Errorf("expected %#v, received %#v", expect, ev)
+	}
+
+	child.next(t)
+
+	_, err = d.Shutdown(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pending, err := j.Pending()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(pending) != 0 {
+		t.Errorf("expected no pending dispatches, received %#v", pending)
+	}
+}
+
+func TestOrchestrator_Replay_UnknownProcess(t *testing.T) {
+	j, err := orchestrator.OpenFileJournal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer j.Close()
+
+	_, err = j.Append(orchestrator.Dispatch{Parent: "input", Process: "missing"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := setupOrchestrator(t, testDAG{
+		before: func(d *orchestrator.Orchestrator) { d.SetJournal(j) },
+	})
+
+	n, err := d.Replay()
+
+	var upe orchestrator.UnknownProcessError
+	if !errors.As(err, &upe) {
+		t.Errorf("expected UnknownProcessError, received %#v", err)
+	}
+
+	if n != 0 {
+		t.Errorf("expected 0 replayed dispatches, received %d", n)
+	}
+}
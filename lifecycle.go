@@ -0,0 +1,171 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+)
+
+// ErrOrchestratorClosed is returned by Run once Shutdown has been called, and
+// by any function which cannot be used on an Orchestrator which has been shut down
+var ErrOrchestratorClosed = errors.New("orchestrator: orchestrator has been shut down")
+
+// Dispatch represents a single Event being delivered to a Process from
+// either an Input, or a parent Process, linked to it
+type Dispatch struct {
+	// ID uniquely identifies this Dispatch, and is created by the
+	// Orchestrator's IDGenerator
+	ID string `json:"id"`
+
+	// Parent is the ID of the Input or Process which triggered this
+	// Dispatch
+	Parent  string `json:"parent"`
+	Process string `json:"process"`
+	Event   Event  `json:"event"`
+}
+
+// ShutdownSummary is returned by Shutdown, and contains details of any work
+// which was abandoned as part of shutting down
+type ShutdownSummary struct {
+	// Abandoned contains any Dispatch which was still in-flight when the
+	// shutdown deadline was reached, and so whose Process had its context
+	// cancelled
+	Abandoned []Dispatch
+}
+
+// Run blocks until either ctx is cancelled, or Shutdown is called.
+//
+// When Shutdown is called, Run returns ErrOrchestratorClosed. When ctx is
+// cancelled, Run shuts the Orchestrator down without waiting for in-flight
+// processes to complete, and returns the error from ctx. Callers wanting
+// processes to be drained gracefully should call Shutdown with a suitable
+// deadline instead
+func (d Orchestrator) Run(ctx context.Context) error {
+	select {
+	case <-d.done:
+		return ErrOrchestratorClosed
+
+	case <-ctx.Done():
+		d.Shutdown(ctx)
+
+		return ctx.Err()
+	}
+}
+
+// Shutdown gracefully stops the Orchestrator. It:
+//
+//  1. Stops accepting new Events
+//  2. Cancels the context of every Input
+//  3. Waits for in-flight processes to complete, until ctx is done
+//
+// Any process still running when ctx is done has its context cancelled, and
+// is returned in the ShutdownSummary, along with the error from ctx.
+//
+// Calling Shutdown more than once returns ErrOrchestratorClosed
+func (d Orchestrator) Shutdown(ctx context.Context) (summary ShutdownSummary, err error) {
+	if !d.closed.CompareAndSwap(false, true) {
+		return summary, ErrOrchestratorClosed
+	}
+
+	defer close(d.done)
+
+	d.logger.Info("orchestrator shutting down")
+
+	d.cancel()
+	drained := d.dispatches.close()
+
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		summary.Abandoned = d.dispatches.list()
+		err = ctx.Err()
+	}
+
+	d.processCancel()
+
+	d.logger.Info("orchestrator shut down", slog.Int("abandoned", len(summary.Abandoned)))
+
+	return
+}
+
+// dispatchTracker keeps a record of in-flight Dispatches so that shutdown
+// can wait on them, and report on those it gave up waiting on
+type dispatchTracker struct {
+	mutex   sync.Mutex
+	next    uint64
+	active  map[uint64]Dispatch
+	closed  bool
+	drained chan struct{}
+}
+
+func newDispatchTracker() *dispatchTracker {
+	return &dispatchTracker{
+		active:  make(map[uint64]Dispatch),
+		drained: make(chan struct{}),
+	}
+}
+
+// add records a Dispatch as in-flight, returning false where the tracker
+// has been closed and so the Dispatch should not be run.
+//
+// Follow-on Dispatches, which are triggered by an in-flight Dispatch, are
+// accepted while the tracker drains
+func (t *dispatchTracker) add(d Dispatch, followOn bool) (ref uint64, ok bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.closed && !followOn {
+		return
+	}
+
+	t.next++
+	t.active[t.next] = d
+
+	return t.next, true
+}
+
+func (t *dispatchTracker) remove(ref uint64) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	delete(t.active, ref)
+
+	if t.closed && len(t.active) == 0 {
+		t.closeDrained()
+	}
+}
+
+// close stops the tracker from accepting new Dispatches, returning a
+// channel which is closed once every in-flight Dispatch completes
+func (t *dispatchTracker) close() <-chan struct{} {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.closed = true
+	if len(t.active) == 0 {
+		t.closeDrained()
+	}
+
+	return t.drained
+}
+
+func (t *dispatchTracker) closeDrained() {
+	select {
+	case <-t.drained:
+	default:
+		close(t.drained)
+	}
+}
+
+func (t *dispatchTracker) list() (l []Dispatch) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	l = make([]Dispatch, 0, len(t.active))
+	for _, d := range t.active {
+		l = append(l, d)
+	}
+
+	return
+}
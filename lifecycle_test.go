@@ -0,0 +1,177 @@
+package orchestrator_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dapper-data/dapper-orchestrator"
+)
+
+// onceInput sends a single event, and then waits to be told to stop.
+//
+// It waits a short while before sending, to give tests time to link it
+// to processes
+type onceInput struct {
+	id string
+}
+
+func (i onceInput) Handle(ctx context.Context, c chan orchestrator.Event) error {
+	time.Sleep(time.Millisecond * 10)
+
+	select {
+	case c <- orchestrator.Event{ID: "1", Trigger: i.id}:
+	case <-ctx.Done():
+	}
+
+	<-ctx.Done()
+
+	return ctx.Err()
+}
+
+func (i onceInput) ID() string {
+	return i.id
+}
+
+// sleepyProcess sleeps for a set duration, or until its context is cancelled
+type sleepyProcess struct {
+	id       string
+	duration time.Duration
+	started  chan struct{}
+	finished chan error
+}
+
+func newSleepyProcess(id string, duration time.Duration) *sleepyProcess {
+	return &sleepyProcess{
+		id:       id,
+		duration: duration,
+		started:  make(chan struct{}, 1),
+		finished: make(chan error, 1),
+	}
+}
+
+func (p *sleepyProcess) Run(ctx context.Context, _ orchestrator.Event) (ps orchestrator.ProcessStatus, err error) {
+	p.started <- struct{}{}
+
+	select {
+	case <-time.After(p.duration):
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	p.finished <- err
+
+	return
+}
+
+func (p *sleepyProcess) ID() string {
+	return p.id
+}
+
+func setupShutdownTest(t *testing.T, duration time.Duration) (*orchestrator.Orchestrator, *sleepyProcess) {
+	t.Helper()
+
+	p := newSleepyProcess("sleepy-process", duration)
+	d := setupOrchestrator(t, testDAG{
+		process: p,
+		inputs:  []orchestrator.Input{onceInput{id: "once-input"}},
+	})
+
+	select {
+	case <-p.started:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for process to start")
+	}
+
+	return d, p
+}
+
+func TestOrchestrator_Shutdown_Drains(t *testing.T) {
+	d, p := setupShutdownTest(t, time.Millisecond*50)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	summary, err := d.Shutdown(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error %#v", err)
+	}
+
+	if len(summary.Abandoned) != 0 {
+		t.Errorf("expected no abandoned dispatches, received %#v", summary.Abandoned)
+	}
+
+	err = <-p.finished
+	if err != nil {
+		t.Errorf("expected process to complete, received %#v", err)
+	}
+}
+
+func TestOrchestrator_Shutdown_Abandons(t *testing.T) {
+	d, p := setupShutdownTest(t, time.Minute)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+
+	summary, err := d.Shutdown(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, received %#v", err)
+	}
+
+	if len(summary.Abandoned) != 1 {
+		t.Fatalf("expected 1 abandoned dispatch, received %d", len(summary.Abandoned))
+	}
+
+	if summary.Abandoned[0].Process != "sleepy-process" {
+		t.Errorf("expected sleepy-process to be abandoned, received %q", summary.Abandoned[0].Process)
+	}
+
+	select {
+	case err = <-p.finished:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, received %#v", err)
+		}
+
+	case <-time.After(time.Second):
+		t.Error("timed out waiting for process to be cancelled")
+	}
+}
+
+func TestOrchestrator_Shutdown_Twice(t *testing.T) {
+	d := orchestrator.New()
+
+	_, err := d.Shutdown(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = d.Shutdown(context.Background())
+	if !errors.Is(err, orchestrator.ErrOrchestratorClosed) {
+		t.Errorf("expected orchestrator.ErrOrchestratorClosed, received %#v", err)
+	}
+
+	err = d.AddInput(context.Background(), onceInput{id: "late"})
+	if !errors.Is(err, orchestrator.ErrOrchestratorClosed) {
+		t.Errorf("expected orchestrator.ErrOrchestratorClosed, received %#v", err)
+	}
+}
+
+func TestOrchestrator_Run(t *testing.T) {
+	d := orchestrator.New()
+
+	errs := make(chan error)
+	go func() {
+		errs <- d.Run(context.Background())
+	}()
+
+	_, err := d.Shutdown(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = <-errs
+	if !errors.Is(err, orchestrator.ErrOrchestratorClosed) {
+		t.Errorf("expected orchestrator.ErrOrchestratorClosed, received %#v", err)
+	}
+}
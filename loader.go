@@ -0,0 +1,306 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+)
+
+var (
+	registryMutex sync.RWMutex
+	inputTypes    = make(map[string]NewInputFunc)
+	processTypes  = make(map[string]NewProcessFunc)
+)
+
+// RegisterInput makes an Input type available to FromConfig, where the
+// type field of an InputConfig is matched against name.
+//
+// RegisterInput panics if called twice with the same name, or if f is nil
+func RegisterInput(name string, f NewInputFunc) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	if f == nil {
+		panic("orchestrator: RegisterInput function is nil")
+	}
+
+	if _, ok := inputTypes[name]; ok {
+		panic("orchestrator: RegisterInput called twice for input type " + name)
+	}
+
+	inputTypes[name] = f
+}
+
+// RegisterProcess makes a Process type available to FromConfig, where the
+// type field of a ProcessConfig is matched against name.
+//
+// RegisterProcess panics if called twice with the same name, or if f is nil
+func RegisterProcess(name string, f NewProcessFunc) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	if f == nil {
+		panic("orchestrator: RegisterProcess function is nil")
+	}
+
+	if _, ok := processTypes[name]; ok {
+		panic("orchestrator: RegisterProcess called twice for process type " + name)
+	}
+
+	processTypes[name] = f
+}
+
+func lookupInput(name string) (f NewInputFunc, ok bool) {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	f, ok = inputTypes[name]
+
+	return
+}
+
+func lookupProcess(name string) (f NewProcessFunc, ok bool) {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	f, ok = processTypes[name]
+
+	return
+}
+
+// ConfigError is returned when a Config is invalid, such as when it
+// references unknown types, or contains duplicate IDs
+type ConfigError struct {
+	// Kind is one of "input", "process", or "link"
+	Kind string
+
+	// Name is the name of the input or process, or the
+	// from -> to of the link, which is invalid
+	Name string
+
+	// Reason describes what is wrong
+	Reason string
+}
+
+// Error returns a descriptive error message
+func (e ConfigError) Error() string {
+	return fmt.Sprintf("invalid %s %q: %s", e.Kind, e.Name, e.Reason)
+}
+
+// LoadConfig parses a TOML pipeline configuration from r, and validates it
+// (see Config.Validate)
+func LoadConfig(r io.Reader) (c Config, err error) {
+	md, err := toml.NewDecoder(r).Decode(&c)
+	if err != nil {
+		return
+	}
+
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		keys := make([]string, len(undecoded))
+		for i, k := range undecoded {
+			keys[i] = k.String()
+		}
+
+		return c, fmt.Errorf("unknown configuration keys: %s", strings.Join(keys, ", "))
+	}
+
+	err = c.Validate()
+
+	return
+}
+
+// Validate checks a Config for errors, returning every problem it finds
+// joined together as ConfigErrors. It checks for:
+//
+//  1. Inputs and Processes with missing or duplicate names
+//  2. Inputs and Processes with types which have not been registered
+//  3. Links to or from unknown Inputs or Processes, or to Inputs
+//  4. Links which create cycles
+func (c Config) Validate() error {
+	errs := make([]error, 0)
+	inputs := make(map[string]bool)
+	processes := make(map[string]bool)
+
+	for _, ic := range c.Inputs {
+		errs = append(errs, validateNode("input", ic.ID(), ic.Type, inputs, processes)...)
+
+		if _, ok := lookupInput(ic.Type); !ok {
+			errs = append(errs, ConfigError{Kind: "input", Name: ic.ID(), Reason: fmt.Sprintf("unknown input type %q", ic.Type)})
+		}
+
+		inputs[ic.ID()] = true
+	}
+
+	for _, pc := range c.Processes {
+		errs = append(errs, validateNode("process", pc.ID(), pc.Type, inputs, processes)...)
+
+		if _, ok := lookupProcess(pc.Type); !ok {
+			errs = append(errs, ConfigError{Kind: "process", Name: pc.ID(), Reason: fmt.Sprintf("unknown process type %q", pc.Type)})
+		}
+
+		if pc.Timeout < 0 {
+			errs = append(errs, ConfigError{Kind: "process", Name: pc.ID(), Reason: "timeout must not be negative"})
+		}
+
+		processes[pc.ID()] = true
+	}
+
+	edges := make(map[string][]string)
+	for _, lc := range c.Links {
+		name := lc.From + " -> " + lc.To
+
+		switch {
+		case !inputs[lc.From] && !processes[lc.From]:
+			errs = append(errs, ConfigError{Kind: "link", Name: name, Reason: fmt.Sprintf("unknown input or process %q", lc.From)})
+
+		case inputs[lc.To]:
+			errs = append(errs, ConfigError{Kind: "link", Name: name, Reason: fmt.Sprintf("%q is an input, and so cannot be linked to", lc.To)})
+
+		case !processes[lc.To]:
+			errs = append(errs, ConfigError{Kind: "link", Name: name, Reason: fmt.Sprintf("unknown process %q", lc.To)})
+
+		default:
+			edges[lc.From] = append(edges[lc.From], lc.To)
+		}
+	}
+
+	errs = append(errs, findCycles(edges)...)
+
+	return errors.Join(errs...)
+}
+
+func validateNode(kind, name, typ string, inputs, processes map[string]bool) (errs []error) {
+	if name == "" {
+		errs = append(errs, ConfigError{Kind: kind, Name: name, Reason: "name must be set"})
+	}
+
+	if inputs[name] || processes[name] {
+		errs = append(errs, ConfigError{Kind: kind, Name: name, Reason: "duplicate name"})
+	}
+
+	if typ == "" {
+		errs = append(errs, ConfigError{Kind: kind, Name: name, Reason: "type must be set"})
+	}
+
+	return
+}
+
+// findCycles returns a ConfigError for each link which closes a cycle in edges
+func findCycles(edges map[string][]string) (errs []error) {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	state := make(map[string]int)
+
+	var visit func(string)
+	visit = func(from string) {
+		state[from] = visiting
+
+		for _, to := range edges[from] {
+			switch state[to] {
+			case visiting:
+				errs = append(errs, ConfigError{Kind: "link", Name: from + " -> " + to, Reason: "link creates a cycle"})
+
+			case unvisited:
+				visit(to)
+			}
+		}
+
+		state[from] = visited
+	}
+
+	// Sort for deterministic errors
+	froms := make([]string, 0, len(edges))
+	for from := range edges {
+		froms = append(froms, from)
+	}
+
+	sort.Strings(froms)
+
+	for _, from := range froms {
+		if state[from] == unvisited {
+			visit(from)
+		}
+	}
+
+	return
+}
+
+// FromConfig validates c, creates each Input and Process it contains using
+// the types registered with RegisterInput and RegisterProcess, and returns an
+// Orchestrator with everything linked together.
+//
+// The Orchestrator is created with opts (see New). Inputs are started with ctx
+// only once every link has been created, so that no Events are lost while the
+// Orchestrator is built
+func FromConfig(ctx context.Context, c Config, opts ...Option) (d *Orchestrator, err error) {
+	err = c.Validate()
+	if err != nil {
+		return
+	}
+
+	inputs := make([]Input, len(c.Inputs))
+	for idx, ic := range c.Inputs {
+		f, _ := lookupInput(ic.Type)
+
+		inputs[idx], err = f(ic)
+		if err != nil {
+			return nil, fmt.Errorf("creating input %q: %w", ic.ID(), err)
+		}
+	}
+
+	processes := make([]Process, len(c.Processes))
+	for idx, pc := range c.Processes {
+		f, _ := lookupProcess(pc.Type)
+
+		processes[idx], err = f(pc)
+		if err != nil {
+			return nil, fmt.Errorf("creating process %q: %w", pc.ID(), err)
+		}
+	}
+
+	d = New(opts...)
+
+	entries := make([]*inputEntry, len(inputs))
+	for idx, i := range inputs {
+		entries[idx], err = d.addInput(i, []InputOption{
+			WithOperations(c.Inputs[idx].Operations...),
+			WithInputConcurrency(c.Inputs[idx].Concurrency),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("adding input %q: %w", i.ID(), err)
+		}
+	}
+
+	for idx, p := range processes {
+		err = d.AddProcess(p,
+			WithTimeout(c.Processes[idx].Timeout),
+			WithConcurrency(c.Processes[idx].Concurrency),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("adding process %q: %w", p.ID(), err)
+		}
+	}
+
+	for _, lc := range c.Links {
+		err = d.addLink(lc.From, lc.To, []LinkOption{WithLinkOperations(lc.Operations...)})
+		if err != nil {
+			return nil, fmt.Errorf("adding link %q -> %q: %w", lc.From, lc.To, err)
+		}
+	}
+
+	for _, ie := range entries {
+		d.startInput(ctx, ie)
+	}
+
+	return
+}
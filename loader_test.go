@@ -0,0 +1,186 @@
+package orchestrator_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dapper-data/dapper-orchestrator"
+)
+
+// loaderProcesses holds the processes created by FromConfig in these tests,
+// so that tests can inspect them
+var loaderProcesses = make(map[string]*recordingProcess)
+
+func init() {
+	orchestrator.RegisterInput("sequence", func(ic orchestrator.InputConfig) (orchestrator.Input, error) {
+		return sequenceInput{
+			id: ic.ID(),
+			events: []orchestrator.Event{
+				{ID: "1", Operation: orchestrator.OperationCreate},
+				{ID: "2", Operation: orchestrator.OperationDelete},
+			},
+		}, nil
+	})
+
+	orchestrator.RegisterProcess("recording", func(pc orchestrator.ProcessConfig) (orchestrator.Process, error) {
+		p := newRecordingProcess(pc.ID(), orchestrator.ProcessSuccess)
+		loaderProcesses[pc.ID()] = p
+
+		return p, nil
+	})
+
+	orchestrator.RegisterProcess("broken", func(pc orchestrator.ProcessConfig) (orchestrator.Process, error) {
+		return nil, errors.New(pc.ExecutionContext["error"])
+	})
+}
+
+const validConfig = `
+[[input]]
+name = "raw_writes"
+type = "sequence"
+operation = ["insert"]
+
+[[process]]
+name = "raw_to_cleansed"
+type = "recording"
+timeout = "30s"
+concurrency = 2
+
+[[process]]
+name = "cleansed_to_reporting"
+type = "recording"
+execution_context = { some = "value" }
+
+[[link]]
+from = "raw_writes"
+to = "raw_to_cleansed"
+
+[[link]]
+from = "raw_to_cleansed"
+to = "cleansed_to_reporting"
+`
+
+func TestLoadConfig(t *testing.T) {
+	c, err := orchestrator.LoadConfig(strings.NewReader(validConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(c.Inputs) != 1 || len(c.Processes) != 2 || len(c.Links) != 2 {
+		t.Fatalf("unexpected config %#v", c)
+	}
+
+	if len(c.Inputs[0].Operations) != 1 || c.Inputs[0].Operations[0] != orchestrator.OperationCreate {
+		t.Errorf("expected operations [create], received %v", c.Inputs[0].Operations)
+	}
+
+	if c.Processes[0].Timeout != time.Second*30 {
+		t.Errorf("expected timeout %s, received %s", time.Second*30, c.Processes[0].Timeout)
+	}
+
+	if c.Processes[0].Concurrency != 2 {
+		t.Errorf("expected concurrency 2, received %d", c.Processes[0].Concurrency)
+	}
+
+	if c.Processes[1].ExecutionContext["some"] != "value" {
+		t.Errorf("expected execution_context to be loaded, received %#v", c.Processes[1].ExecutionContext)
+	}
+}
+
+func TestLoadConfig_Errors(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		config string
+		expect []string
+	}{
+		{"invalid toml", `[[input]`, nil},
+		{"unknown key", "[[input]]\nname = \"a\"\ntype = \"sequence\"\ncolour = \"blue\"", []string{"unknown configuration keys: input.colour"}},
+		{"unknown operation", "[[input]]\nname = \"a\"\ntype = \"sequence\"\noperation = [\"upsert\"]", nil},
+		{"missing name", "[[input]]\ntype = \"sequence\"", []string{`invalid input "": name must be set`}},
+		{"missing type", "[[process]]\nname = \"a\"", []string{`invalid process "a": type must be set`, `invalid process "a": unknown process type ""`}},
+		{"unknown types", "[[input]]\nname = \"a\"\ntype = \"kafka\"\n[[process]]\nname = \"b\"\ntype = \"docker\"", []string{`invalid input "a": unknown input type "kafka"`, `invalid process "b": unknown process type "docker"`}},
+		{"duplicate names", "[[input]]\nname = \"a\"\ntype = \"sequence\"\n[[process]]\nname = \"a\"\ntype = \"recording\"", []string{`invalid process "a": duplicate name`}},
+		{"dangling links", "[[input]]\nname = \"a\"\ntype = \"sequence\"\n[[link]]\nfrom = \"a\"\nto = \"b\"\n[[link]]\nfrom = \"c\"\nto = \"a\"", []string{`invalid link "a -> b": unknown process "b"`, `invalid link "c -> a": unknown input or process "c"`}},
+		{"link to input", "[[input]]\nname = \"a\"\ntype = \"sequence\"\n[[input]]\nname = \"b\"\ntype = \"sequence\"\n[[link]]\nfrom = \"a\"\nto = \"b\"", []string{`invalid link "a -> b": "b" is an input, and so cannot be linked to`}},
+		{"negative timeout", "[[process]]\nname = \"a\"\ntype = \"recording\"\ntimeout = \"-1s\"", []string{`invalid process "a": timeout must not be negative`}},
+		{"cycle", "[[process]]\nname = \"a\"\ntype = \"recording\"\n[[process]]\nname = \"b\"\ntype = \"recording\"\n[[link]]\nfrom = \"a\"\nto = \"b\"\n[[link]]\nfrom = \"b\"\nto = \"a\"", []string{`invalid link "b -> a": link creates a cycle`}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := orchestrator.LoadConfig(strings.NewReader(test.config))
+			if err == nil {
+				t.Fatal("expected error, received none")
+			}
+
+			for _, expect := range test.expect {
+				if !strings.Contains(err.Error(), expect) {
+					t.Errorf("expected error to contain\n%s\nreceived\n%s", expect, err.Error())
+				}
+			}
+		})
+	}
+}
+
+func TestFromConfig(t *testing.T) {
+	c, err := orchestrator.LoadConfig(strings.NewReader(validConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := orchestrator.FromConfig(context.Background(), c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer d.Shutdown(context.Background())
+
+	// Only the insert should make it through, all the way to reporting
+	for _, id := range []string{"raw_to_cleansed", "cleansed_to_reporting"} {
+		t.Run(id, func(t *testing.T) {
+			p := loaderProcesses[id]
+
+			ev := p.next(t)
+			if ev.ID != "1" {
+				t.Errorf("expected event ID %q, received %q", "1", ev.ID)
+			}
+
+			p.expectNoRun(t)
+		})
+	}
+}
+
+func TestFromConfig_ConstructorError(t *testing.T) {
+	_, err := orchestrator.FromConfig(context.Background(), orchestrator.Config{
+		Processes: []orchestrator.ProcessConfig{
+			{Name: "a", Type: "broken", ExecutionContext: map[string]string{"error": "oh no"}},
+		},
+	})
+
+	expect := `creating process "a": oh no`
+	if err == nil || err.Error() != expect {
+		t.Errorf("expected %q, received %v", expect, err)
+	}
+}
+
+func TestRegisterInput_Duplicate(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic, received none")
+		}
+	}()
+
+	orchestrator.RegisterInput("sequence", func(orchestrator.InputConfig) (orchestrator.Input, error) {
+		return nil, nil
+	})
+}
+
+func TestConfigError_Error(t *testing.T) {
+	expect := `invalid link "a -> b": link creates a cycle`
+	err := orchestrator.ConfigError{Kind: "link", Name: "a -> b", Reason: "link creates a cycle"}
+
+	if expect != err.Error() {
+		t.Errorf("expected\n%s\nreceived\n%s", expect, err.Error())
+	}
+}
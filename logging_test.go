@@ -0,0 +1,112 @@
+package orchestrator_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/dapper-data/dapper-orchestrator"
+)
+
+// syncBuffer is a bytes.Buffer which is safe to use across goroutines
+type syncBuffer struct {
+	mutex sync.Mutex
+	buf   bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return b.buf.Write(p)
+}
+
+// records returns every logged record with the message msg
+func (b *syncBuffer) records(t *testing.T, msg string) (records []map[string]any) {
+	t.Helper()
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for _, line := range strings.Split(strings.TrimSpace(b.buf.String()), "\n") {
+		var r map[string]any
+
+		err := json.Unmarshal([]byte(line), &r)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if r["msg"] == msg {
+			records = append(records, r)
+		}
+	}
+
+	return
+}
+
+func TestOrchestrator_WithLogger(t *testing.T) {
+	buf := new(syncBuffer)
+	logger := slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	p := newRecordingProcess("recording", orchestrator.ProcessSuccess)
+	p.status.Logs = []string{"hello", "world"}
+
+	d := setupOrchestrator(t, testDAG{
+		opts:    []orchestrator.Option{orchestrator.WithLogger(logger)},
+		process: p,
+		inputs:  []orchestrator.Input{onceInput{id: "once-input"}},
+	})
+
+	p.next(t)
+
+	_, err := d.Shutdown(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r := buf.records(t, "input started"); len(r) != 1 || r[0]["input"] != "once-input" {
+		t.Errorf("expected input started to be logged, received %v", r)
+	}
+
+	logs := buf.records(t, "process log")
+	if len(logs) != 2 {
+		t.Fatalf("expected 2 process logs, received %v", logs)
+	}
+
+	for idx, expect := range []string{"hello", "world"} {
+		if logs[idx]["log"] != expect {
+			t.Errorf("expected %q, received %q", expect, logs[idx]["log"])
+		}
+	}
+
+	finished := buf.records(t, "process finished")
+	if len(finished) != 1 {
+		t.Fatalf("expected 1 process finished record, received %v", finished)
+	}
+
+	for k, v := range map[string]any{
+		"input":    "once-input",
+		"parent":   "once-input",
+		"process":  "recording",
+		"event_id": "1",
+		"trigger":  "once-input",
+		"status":   "success",
+		"attempt":  float64(1),
+	} {
+		if finished[0][k] != v {
+			t.Errorf("%s: expected %v, received %v", k, v, finished[0][k])
+		}
+	}
+
+	if _, ok := finished[0]["duration"]; !ok {
+		t.Error("expected duration to be logged")
+	}
+
+	if r := buf.records(t, "orchestrator shut down"); len(r) != 1 {
+		t.Errorf("expected shut down to be logged, received %v", r)
+	}
+}
@@ -0,0 +1,100 @@
+package orchestrator
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// metrics holds the prometheus collectors an Orchestrator records to,
+// and is nil where metrics have not been enabled (see WithMetricsRegistry)
+type metrics struct {
+	eventsReceived  *prometheus.CounterVec
+	dispatches      *prometheus.CounterVec
+	processRuns     *prometheus.CounterVec
+	processDuration *prometheus.HistogramVec
+	queueDuration   *prometheus.HistogramVec
+	inFlight        *prometheus.GaugeVec
+}
+
+func newMetrics(reg prometheus.Registerer) *metrics {
+	m := &metrics{
+		eventsReceived: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "orchestrator",
+			Name:      "events_received_total",
+			Help:      "Events received from each input, before any filtering",
+		}, []string{"input"}),
+		dispatches: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "orchestrator",
+			Name:      "dispatches_total",
+			Help:      "Events dispatched along each link, from an input or process to a process",
+		}, []string{"parent", "process"}),
+		processRuns: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "orchestrator",
+			Name:      "process_runs_total",
+			Help:      "Process runs, including retries, by process and exit status",
+		}, []string{"process", "status"}),
+		processDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "orchestrator",
+			Name:      "process_run_duration_seconds",
+			Help:      "Time taken by each process run",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"process"}),
+		queueDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "orchestrator",
+			Name:      "process_queue_duration_seconds",
+			Help:      "Time each process run spent waiting on concurrency limits before starting",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"process"}),
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "orchestrator",
+			Name:      "processes_in_flight",
+			Help:      "Process runs currently in progress",
+		}, []string{"process"}),
+	}
+
+	reg.MustRegister(
+		m.eventsReceived,
+		m.dispatches,
+		m.processRuns,
+		m.processDuration,
+		m.queueDuration,
+		m.inFlight,
+	)
+
+	return m
+}
+
+func (m *metrics) eventReceived(input string) {
+	if m != nil {
+		m.eventsReceived.WithLabelValues(input).Inc()
+	}
+}
+
+func (m *metrics) dispatched(parent, process string) {
+	if m != nil {
+		m.dispatches.WithLabelValues(parent, process).Inc()
+	}
+}
+
+func (m *metrics) queued(process string, d time.Duration) {
+	if m != nil {
+		m.queueDuration.WithLabelValues(process).Observe(d.Seconds())
+	}
+}
+
+// started records a process run starting, returning a function to
+// record it finishing
+func (m *metrics) started(process string) func(ProcessExitStatus, time.Duration) {
+	if m == nil {
+		return func(ProcessExitStatus, time.Duration) {}
+	}
+
+	m.inFlight.WithLabelValues(process).Inc()
+
+	return func(status ProcessExitStatus, d time.Duration) {
+		m.inFlight.WithLabelValues(process).Dec()
+		m.processRuns.WithLabelValues(process, status.String()).Inc()
+		m.processDuration.WithLabelValues(process).Observe(d.Seconds())
+	}
+}
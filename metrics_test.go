@@ -0,0 +1,93 @@
+package orchestrator_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/dapper-data/dapper-orchestrator"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestOrchestrator_WithMetricsRegistry(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+
+	d := orchestrator.New(
+		orchestrator.WithMetricsRegistry(reg),
+		orchestrator.WithErrorHandler(orchestrator.ErrorHandlerFunc(func(error) {})),
+	)
+
+	i := onceInput{id: "once-input"}
+	a := newRecordingProcess("a", orchestrator.ProcessSuccess)
+	b := newRecordingProcess("b", orchestrator.ProcessFail)
+
+	for _, p := range []orchestrator.Process{a, b} {
+		err := d.AddProcess(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := d.AddInput(context.Background(), i)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = d.AddLink(i, a)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = d.AddProcessLink(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a.next(t)
+	b.next(t)
+
+	_, err = d.Shutdown(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := `
+# HELP orchestrator_dispatches_total Events dispatched along each link, from an input or process to a process
+# TYPE orchestrator_dispatches_total counter
+orchestrator_dispatches_total{parent="a",process="b"} 1
+orchestrator_dispatches_total{parent="once-input",process="a"} 1
+# HELP orchestrator_events_received_total Events received from each input, before any filtering
+# TYPE orchestrator_events_received_total counter
+orchestrator_events_received_total{input="once-input"} 1
+# HELP orchestrator_process_runs_total Process runs, including retries, by process and exit status
+# TYPE orchestrator_process_runs_total counter
+orchestrator_process_runs_total{process="a",status="success"} 1
+orchestrator_process_runs_total{process="b",status="fail"} 1
+# HELP orchestrator_processes_in_flight Process runs currently in progress
+# TYPE orchestrator_processes_in_flight gauge
+orchestrator_processes_in_flight{process="a"} 0
+orchestrator_processes_in_flight{process="b"} 0
+`
+
+	err = testutil.GatherAndCompare(reg, strings.NewReader(expect),
+		"orchestrator_dispatches_total",
+		"orchestrator_events_received_total",
+		"orchestrator_process_runs_total",
+		"orchestrator_processes_in_flight",
+	)
+	if err != nil {
+		t.Error(err)
+	}
+
+	for _, name := range []string{"orchestrator_process_run_duration_seconds", "orchestrator_process_queue_duration_seconds"} {
+		count, err := testutil.GatherAndCount(reg, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if count != 2 {
+			t.Errorf("%s: expected 2 series, received %d", name, count)
+		}
+	}
+}
@@ -22,7 +22,8 @@ type Operation uint8
 
 // UnmarshalText implements the encoding.TextUnmarshaler interface
 // allowing for a byte slice containing certain crud operations to be
-// cast to Operations
+// cast to Operations. "unknown" is accepted so that the output of
+// MarshalText can always be read back
 func (o *Operation) UnmarshalText(b []byte) error {
 	switch strings.ToLower(string(b)) {
 	case "create", "insert":
@@ -33,6 +34,8 @@ func (o *Operation) UnmarshalText(b []byte) error {
 		*o = OperationUpdate
 	case "delete", "remove":
 		*o = OperationDelete
+	case "unknown":
+		*o = OperationUnknown
 
 	default:
 		return fmt.Errorf("Unknown operation %q", string(b))
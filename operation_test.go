@@ -21,6 +21,7 @@ func TestOperation_UnmarshalText(t *testing.T) {
 		{"update", orchestrator.OperationUpdate, false},
 		{"delete", orchestrator.OperationDelete, false},
 		{"remove", orchestrator.OperationDelete, false},
+		{"unknown", orchestrator.OperationUnknown, false},
 
 		// Error cases
 		{"new", orchestrator.OperationUnknown, true},
@@ -56,6 +57,7 @@ func TestOperation_UnmarshalJSON(t *testing.T) {
 		{"update", orchestrator.OperationUpdate, false},
 		{"delete", orchestrator.OperationDelete, false},
 		{"remove", orchestrator.OperationDelete, false},
+		{"unknown", orchestrator.OperationUnknown, false},
 
 		// Error cases
 		{"new", orchestrator.OperationUnknown, true},
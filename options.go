@@ -0,0 +1,352 @@
+package orchestrator
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Option configures an Orchestrator, and is passed to New
+type Option func(*options)
+
+type options struct {
+	concurrency    int
+	errorBuffer    int
+	logger         *slog.Logger
+	clock          Clock
+	newID          IDGenerator
+	defaultTimeout time.Duration
+	errorHandler   ErrorHandler
+	dropPolicy     DropPolicy
+	registry       prometheus.Registerer
+	tracerProvider trace.TracerProvider
+	propagator     propagation.TextMapPropagator
+	runStore       RunStore
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		concurrency: int(ConcurrentProcessors),
+		logger:      slog.Default(),
+		clock:       SystemClock{},
+		newID:       RandomID,
+		errorBuffer: DefaultErrorBuffer,
+		propagator:  propagation.TraceContext{},
+	}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	if o.tracerProvider == nil {
+		o.tracerProvider = otel.GetTracerProvider()
+	}
+
+	if o.errorHandler == nil {
+		o.errorHandler = LogErrorHandler{Logger: o.logger}
+	}
+
+	return o
+}
+
+// WithGlobalConcurrency sets the number of Processes which may run at once,
+// across the whole Orchestrator, defaulting to 8. Zero or less removes the
+// limit. The limit can be changed later with SetConcurrency
+func WithGlobalConcurrency(n int) Option {
+	return func(o *options) {
+		o.concurrency = n
+	}
+}
+
+// WithErrorBuffer sets the size of the buffer of ErrorChan, defaulting to
+// DefaultErrorBuffer. Values below zero are treated as zero, leaving ErrorChan
+// unbuffered
+func WithErrorBuffer(n int) Option {
+	return func(o *options) {
+		o.errorBuffer = max(n, 0)
+	}
+}
+
+// WithErrorDropPolicy sets what happens to errors sent to a full ErrorChan,
+// defaulting to DropNewest. Dropped errors are counted (see DroppedErrors),
+// and will still have been passed to the ErrorHandler
+func WithErrorDropPolicy(p DropPolicy) Option {
+	return func(o *options) {
+		o.dropPolicy = p
+	}
+}
+
+// WithErrorHandler sets the ErrorHandler every error is passed to, which
+// defaults to a LogErrorHandler logging to the Orchestrator's logger
+func WithErrorHandler(h ErrorHandler) Option {
+	return func(o *options) {
+		o.errorHandler = h
+	}
+}
+
+// WithLogger sets the logger an Orchestrator logs to, defaulting to
+// slog.Default().
+//
+// Inputs starting and stopping, Processes starting and finishing (with their
+// durations), and the Logs from each ProcessStatus are logged with attributes
+// such as input, process, event_id and trigger, allowing them to be filtered
+// and aggregated
+func WithLogger(l *slog.Logger) Option {
+	return func(o *options) {
+		if l != nil {
+			o.logger = l
+		}
+	}
+}
+
+// WithMetricsRegistry enables metrics, registering them on reg. Metrics are
+// namespaced with orchestrator_, and include:
+//
+//	orchestrator_events_received_total{input}
+//	orchestrator_dispatches_total{parent, process}
+//	orchestrator_process_runs_total{process, status}
+//	orchestrator_process_run_duration_seconds{process}
+//	orchestrator_process_queue_duration_seconds{process}
+//	orchestrator_processes_in_flight{process}
+//
+// New panics where the metrics cannot be registered, such as where another
+// Orchestrator has registered them on reg already; multiple Orchestrators can
+// share a registry by wrapping it with prometheus.WrapRegistererWith.
+//
+// By default, metrics are disabled
+func WithMetricsRegistry(reg prometheus.Registerer) Option {
+	return func(o *options) {
+		o.registry = reg
+	}
+}
+
+// WithTracerProvider enables tracing, with spans created by tp, defaulting
+// to the global TracerProvider (see otel.SetTracerProvider).
+//
+// Each Event emitted by an Input gets a span, continuing any trace already in
+// its Metadata. Each Dispatch of that Event gets a child span, which in turn
+// has child spans for waiting on concurrency limits and for each Process.Run.
+//
+// The context passed to Run carries the span for that run, which is also added
+// to the Event's Metadata, under MetadataTraceParent and MetadataTraceState, so
+// that the trace can be continued by things outside of the Orchestrator
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(o *options) {
+		o.tracerProvider = tp
+	}
+}
+
+// WithPropagator sets how trace context is stored in Event Metadata,
+// defaulting to W3C Trace Context
+func WithPropagator(p propagation.TextMapPropagator) Option {
+	return func(o *options) {
+		if p != nil {
+			o.propagator = p
+		}
+	}
+}
+
+// WithRunStore records every run of every Process in s, allowing them to be
+// queried with Orchestrator.Runs. By default, runs are not recorded
+func WithRunStore(s RunStore) Option {
+	return func(o *options) {
+		o.runStore = s
+	}
+}
+
+// WithClock sets the Clock an Orchestrator uses, defaulting to SystemClock
+func WithClock(c Clock) Option {
+	return func(o *options) {
+		if c != nil {
+			o.clock = c
+		}
+	}
+}
+
+// WithIDGenerator sets the IDGenerator used to give each Dispatch an ID,
+// defaulting to RandomID
+func WithIDGenerator(g IDGenerator) Option {
+	return func(o *options) {
+		if g != nil {
+			o.newID = g
+		}
+	}
+}
+
+// WithDefaultTimeout sets the timeout for any Process added without
+// WithTimeout (see WithTimeout). By default, Processes have no timeout
+func WithDefaultTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.defaultTimeout = d
+	}
+}
+
+// InputOption configures how an Orchestrator runs a specific Input, and
+// is passed to AddInput
+type InputOption func(*inputOptions)
+
+type inputOptions struct {
+	restartPolicy RestartPolicy
+	operations    []Operation
+	concurrency   int
+}
+
+func newInputOptions(opts []InputOption) *inputOptions {
+	o := &inputOptions{
+		restartPolicy: DefaultRestartPolicy,
+	}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+// WithRestartPolicy sets the RestartPolicy used when an Input fails,
+// overriding DefaultRestartPolicy
+func WithRestartPolicy(p RestartPolicy) InputOption {
+	return func(o *inputOptions) {
+		o.restartPolicy = p
+	}
+}
+
+// WithOperations drops any Event from an Input whose Operation is not one
+// of ops, such as when an InputConfig specifies Operations.
+//
+// By default, Events of any Operation are allowed
+func WithOperations(ops ...Operation) InputOption {
+	return func(o *inputOptions) {
+		o.operations = ops
+	}
+}
+
+// WithInputConcurrency limits the number of Events from an Input which may be
+// in-flight at once, where an Event is in-flight until every Process it
+// triggers, directly or through links between Processes, has completed. While
+// at the limit, the Input is not read from, applying backpressure to it.
+//
+// By default, or where n is zero or less, there is no limit. The limit can be
+// changed later with SetInputConcurrency
+func WithInputConcurrency(n int) InputOption {
+	return func(o *inputOptions) {
+		o.concurrency = n
+	}
+}
+
+// ProcessOption configures how an Orchestrator runs a specific Process, and
+// is passed to AddProcess
+type ProcessOption func(*processOptions)
+
+type processOptions struct {
+	joinPolicy  JoinPolicy
+	retryPolicy RetryPolicy
+	timeout     time.Duration
+	concurrency int
+}
+
+func newProcessOptions(opts []ProcessOption) *processOptions {
+	o := new(processOptions)
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+// WithJoinPolicy sets the JoinPolicy used to determine when a Process with
+// multiple parents is run. By default, Processes use JoinAny
+func WithJoinPolicy(p JoinPolicy) ProcessOption {
+	return func(o *processOptions) {
+		o.joinPolicy = p
+	}
+}
+
+// WithRetryPolicy sets the RetryPolicy used when a Process returns an error.
+// By default, Processes are not retried
+func WithRetryPolicy(p RetryPolicy) ProcessOption {
+	return func(o *processOptions) {
+		o.retryPolicy = p
+	}
+}
+
+// WithTimeout bounds how long each run of a Process may take. Should a run
+// take longer, its context is cancelled, and a ProcessTimeoutError is returned.
+//
+// By default, or where d is zero, the Orchestrator's default timeout is used
+// (see WithDefaultTimeout), and so Processes may run for as long as they like
+func WithTimeout(d time.Duration) ProcessOption {
+	return func(o *processOptions) {
+		o.timeout = d
+	}
+}
+
+// WithConcurrency limits the number of runs of a Process which may happen
+// at once, on top of the limit across the whole Orchestrator.
+//
+// By default, or where n is zero or less, there is no per-Process limit. The
+// limit can be changed later with SetProcessConcurrency
+func WithConcurrency(n int) ProcessOption {
+	return func(o *processOptions) {
+		o.concurrency = n
+	}
+}
+
+// LinkOption configures a link between an Input or Process and a Process,
+// and is passed to AddLink and AddProcessLink
+type LinkOption func(*linkOptions)
+
+type linkOptions struct {
+	operations []Operation
+}
+
+func newLinkOptions(opts []LinkOption) *linkOptions {
+	o := new(linkOptions)
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+// WithLinkOperations drops any Event passing along a link whose Operation
+// is not one of ops, allowing, say, a Process to only be triggered on inserts.
+//
+// Events are still passed along any other links from the same Input or Process
+func WithLinkOperations(ops ...Operation) LinkOption {
+	return func(o *linkOptions) {
+		o.operations = ops
+	}
+}
+
+// ExportOption configures the output of ExportDOT and ExportMermaid
+type ExportOption func(*exportOptions)
+
+type exportOptions struct {
+	statusColours bool
+}
+
+func newExportOptions(opts []ExportOption) *exportOptions {
+	o := new(exportOptions)
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+// WithStatusColours colours each node of an exported DAG by its live state;
+// Inputs by whether they're running, paused, restarting, or have failed, and
+// Processes by whether they're running, or by the result of their last run
+func WithStatusColours() ExportOption {
+	return func(o *exportOptions) {
+		o.statusColours = true
+	}
+}
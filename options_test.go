@@ -0,0 +1,210 @@
+package orchestrator_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dapper-data/dapper-orchestrator"
+)
+
+// fakeClock always returns the same time, and never waits
+type fakeClock struct {
+	now time.Time
+}
+
+func (c fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c fakeClock) After(time.Duration) <-chan time.Time {
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+
+	return ch
+}
+
+// recordingJournal records every Dispatch appended to it, and never
+// has anything pending
+type recordingJournal struct {
+	mutex      sync.Mutex
+	dispatches []orchestrator.Dispatch
+}
+
+func (j *recordingJournal) Append(d orchestrator.Dispatch) (uint64, error) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	j.dispatches = append(j.dispatches, d)
+
+	return uint64(len(j.dispatches)), nil
+}
+
+func (j *recordingJournal) Ack(uint64) error {
+	return nil
+}
+
+func (j *recordingJournal) Pending() ([]orchestrator.JournalEntry, error) {
+	return nil, nil
+}
+
+// recordingSink records every DeadLetter sent to it
+type recordingSink struct {
+	dls chan orchestrator.DeadLetter
+}
+
+func (s recordingSink) DeadLetter(_ context.Context, dl orchestrator.DeadLetter) error {
+	s.dls <- dl
+
+	return nil
+}
+
+func TestNew_WithErrorBuffer(t *testing.T) {
+	for _, test := range []struct {
+		n      int
+		expect int
+	}{
+		{10, 10},
+		{0, 0},
+		{-1, 0},
+	} {
+		t.Run(fmt.Sprint(test.n), func(t *testing.T) {
+			d := orchestrator.New(orchestrator.WithErrorBuffer(test.n))
+
+			if cap(d.ErrorChan) != test.expect {
+				t.Errorf("expected %d, received %d", test.expect, cap(d.ErrorChan))
+			}
+		})
+	}
+}
+
+func TestNew_WithIDGenerator(t *testing.T) {
+	var n int
+
+	d := orchestrator.New(orchestrator.WithIDGenerator(func() string {
+		n++
+
+		return fmt.Sprintf("id-%d", n)
+	}))
+	defer d.Shutdown(context.Background())
+
+	j := new(recordingJournal)
+	d.SetJournal(j)
+
+	p := newRecordingProcess("recording", orchestrator.ProcessSuccess)
+
+	err := d.AddProcess(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = d.Trigger(p.ID(), orchestrator.Event{ID: "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p.next(t)
+
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	if len(j.dispatches) != 1 || j.dispatches[0].ID != "id-1" {
+		t.Errorf("expected a single dispatch with ID %q, received %#v", "id-1", j.dispatches)
+	}
+}
+
+func TestNew_WithDefaultTimeout(t *testing.T) {
+	d := orchestrator.New(orchestrator.WithDefaultTimeout(time.Millisecond * 20))
+	defer d.Shutdown(context.Background())
+
+	p := slowProcess{delay: time.Second * 10}
+
+	err := d.AddProcess(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = d.Trigger(p.ID(), orchestrator.Event{ID: "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case err = <-d.ErrorChan:
+		expect := orchestrator.ProcessTimeoutError{Process: p.ID(), Timeout: time.Millisecond * 20}
+		if !errors.Is(err, expect) {
+			t.Errorf("expected %#v, received %#v", expect, err)
+		}
+
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for error")
+	}
+}
+
+func TestNew_WithClock(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	d := orchestrator.New(orchestrator.WithClock(fakeClock{now: now}), orchestrator.WithErrorBuffer(1))
+	defer d.Shutdown(context.Background())
+
+	sink := recordingSink{dls: make(chan orchestrator.DeadLetter, 1)}
+	d.SetDeadLetterSink(sink)
+
+	// The backoff between attempts is far longer than this test waits
+	// for, and so the process can only be retried because the clock
+	// doesn't wait
+	p := newFlakyProcess(orchestrator.Retryable(errors.New("oops")), 10)
+
+	err := d.AddProcess(p, orchestrator.WithRetryPolicy(orchestrator.RetryPolicy{
+		MaxAttempts: 3,
+		Backoff:     orchestrator.Backoff{Initial: time.Hour},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = d.Trigger(p.ID(), orchestrator.Event{ID: "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case dl := <-sink.dls:
+		if !dl.Time.Equal(now) {
+			t.Errorf("expected %s, received %s", now, dl.Time)
+		}
+
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for dead letter")
+	}
+
+	if len(p.attempts) != 3 {
+		t.Errorf("expected 3 attempts, received %d", len(p.attempts))
+	}
+}
+
+func TestNew_WithGlobalConcurrency(t *testing.T) {
+	d := orchestrator.New(orchestrator.WithGlobalConcurrency(1))
+	defer d.Shutdown(context.Background())
+
+	p := newGatedProcess("gated")
+
+	err := d.AddProcess(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range []string{"1", "2"} {
+		err = d.Trigger(p.ID(), orchestrator.Event{ID: id})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	p.expectStarts(t, 1)
+
+	close(p.release)
+}
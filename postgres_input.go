@@ -0,0 +1,259 @@
+package orchestrator
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const postgresTriggerFunction = `CREATE OR REPLACE FUNCTION orchestrator_notify() RETURNS trigger AS $$
+DECLARE
+    rec RECORD;
+    pk TEXT;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        rec := OLD;
+    ELSE
+        rec := NEW;
+    END IF;
+
+    SELECT string_agg(to_jsonb(rec) ->> a.attname, ',' ORDER BY array_position(i.indkey::int2[], a.attnum))
+      INTO pk
+      FROM pg_index i
+      JOIN pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = ANY(i.indkey)
+     WHERE i.indrelid = TG_RELID AND i.indisprimary;
+
+    PERFORM pg_notify(TG_ARGV[0], json_build_object(
+        'table', TG_TABLE_NAME,
+        'operation', TG_OP,
+        'id', pk
+    )::text);
+
+    RETURN rec;
+END;
+$$ LANGUAGE plpgsql`
+
+const postgresTablesQuery = `SELECT table_name
+  FROM information_schema.tables
+ WHERE table_schema = current_schema()
+   AND table_type = 'BASE TABLE'`
+
+var postgresUnsafeChars = regexp.MustCompile(`[^a-z0-9_]+`)
+
+func init() {
+	RegisterInput("postgres", NewPostgresInput)
+}
+
+// PostgresInput is an Input which uses postgres LISTEN/NOTIFY to create
+// Events whenever a row is inserted, updated, or deleted.
+//
+// By default, PostgresInput installs a trigger function, orchestrator_notify,
+// and adds a trigger using it to every table in the database's current schema.
+// These triggers notify on Channel with the table name, operation, and the
+// value of the row's primary key (comma separated, for composite keys), which
+// become an Event's Location, Operation, and ID respectively.
+//
+// Where triggers are managed elsewhere, such as in migrations, SkipInstall
+// can be set, in which case PostgresInput expects orchestrator_notify triggers,
+// passing Channel as their argument, to already exist.
+//
+// Connection loss is handled by reconnecting, although notifications sent while
+// disconnected are lost
+type PostgresInput struct {
+	name             string
+	connectionString string
+	connector        *pq.Connector
+
+	// Channel is the name of the channel to LISTEN on
+	Channel string
+
+	// Tables to install triggers on. When empty, every table in the
+	// current schema is used
+	Tables []string
+
+	// SkipInstall stops PostgresInput from installing triggers
+	SkipInstall bool
+
+	// MinReconnectInterval and MaxReconnectInterval bound the time
+	// spent waiting between reconnection attempts
+	MinReconnectInterval time.Duration
+	MaxReconnectInterval time.Duration
+}
+
+// NewPostgresInput accepts an InputConfig and returns a PostgresInput,
+// which implements the orchestrator.Input interface.
+//
+// The ConnectionString of ic is validated, but no connection is made until
+// the PostgresInput is run
+func NewPostgresInput(ic InputConfig) (Input, error) {
+	if ic.ID() == "" {
+		return nil, errors.New("postgres input: name must be set")
+	}
+
+	connector, err := pq.NewConnector(ic.ConnectionString)
+	if err != nil {
+		return nil, fmt.Errorf("postgres input: %w", err)
+	}
+
+	return PostgresInput{
+		name:                 ic.ID(),
+		connectionString:     ic.ConnectionString,
+		connector:            connector,
+		Channel:              "orchestrator_" + postgresUnsafeChars.ReplaceAllString(strings.ToLower(ic.ID()), "_"),
+		MinReconnectInterval: time.Second,
+		MaxReconnectInterval: time.Minute,
+	}, nil
+}
+
+// ID returns the ID for this Input
+func (p PostgresInput) ID() string {
+	return p.name
+}
+
+// Handle installs triggers (unless SkipInstall is set), and then listens
+// for notifications, turning them into Events
+func (p PostgresInput) Handle(ctx context.Context, c chan Event) (err error) {
+	if !p.SkipInstall {
+		err = p.install(ctx)
+		if err != nil {
+			return
+		}
+	}
+
+	l := pq.NewListener(p.connectionString, p.MinReconnectInterval, p.MaxReconnectInterval, nil)
+	defer l.Close()
+
+	err = l.Listen(p.Channel)
+	if err != nil {
+		return
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case n := <-l.Notify:
+			// A nil notification means we've reconnected, and
+			// there's nothing to do
+			if n == nil {
+				continue
+			}
+
+			var e Event
+
+			e, err = p.event(n.Extra)
+			if err != nil {
+				return
+			}
+
+			select {
+			case c <- e:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+
+		case <-time.After(time.Minute):
+			// Check our connection is still alive; the listener
+			// handles reconnecting should it not be
+			go l.Ping()
+		}
+	}
+}
+
+// install creates the orchestrator_notify trigger function, and adds it
+// to tables
+func (p PostgresInput) install(ctx context.Context) (err error) {
+	db := sql.OpenDB(p.connector)
+	defer db.Close()
+
+	tables := p.Tables
+	if len(tables) == 0 {
+		tables, err = p.tables(ctx, db)
+		if err != nil {
+			return
+		}
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return
+	}
+
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, postgresTriggerFunction)
+	if err != nil {
+		return
+	}
+
+	trigger := pq.QuoteIdentifier(p.Channel)
+	for _, table := range tables {
+		table = pq.QuoteIdentifier(table)
+
+		_, err = tx.ExecContext(ctx, fmt.Sprintf("DROP TRIGGER IF EXISTS %s ON %s", trigger, table))
+		if err != nil {
+			return
+		}
+
+		_, err = tx.ExecContext(ctx, fmt.Sprintf("CREATE TRIGGER %s AFTER INSERT OR UPDATE OR DELETE ON %s FOR EACH ROW EXECUTE PROCEDURE orchestrator_notify(%s)", trigger, table, pq.QuoteLiteral(p.Channel)))
+		if err != nil {
+			return
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (p PostgresInput) tables(ctx context.Context, db *sql.DB) (tables []string, err error) {
+	rows, err := db.QueryContext(ctx, postgresTablesQuery)
+	if err != nil {
+		return
+	}
+
+	defer rows.Close()
+
+	tables = make([]string, 0)
+	for rows.Next() {
+		var table string
+
+		err = rows.Scan(&table)
+		if err != nil {
+			return
+		}
+
+		tables = append(tables, table)
+	}
+
+	return tables, rows.Err()
+}
+
+// postgresNotification is the payload sent by orchestrator_notify
+type postgresNotification struct {
+	Table     string    `json:"table"`
+	Operation Operation `json:"operation"`
+	ID        string    `json:"id"`
+}
+
+func (p PostgresInput) event(payload string) (e Event, err error) {
+	n := new(postgresNotification)
+
+	err = json.Unmarshal([]byte(payload), n)
+	if err != nil {
+		return
+	}
+
+	return Event{
+		Location:  n.Table,
+		Operation: n.Operation,
+		ID:        n.ID,
+		Trigger:   p.name,
+	}, nil
+}
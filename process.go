@@ -2,6 +2,8 @@ package orchestrator
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 )
 
 // ProcessExitStatus represents the final status of a Process
@@ -15,12 +17,75 @@ const (
 	ProcessFail
 )
 
+// String returns the string representation of a ProcessExitStatus, or
+// "unknown" for any value it doesn't know about
+func (s ProcessExitStatus) String() string {
+	switch s {
+	case ProcessUnstarted:
+		return "unstarted"
+	case ProcessSuccess:
+		return "success"
+	case ProcessFail:
+		return "fail"
+	}
+
+	return "unknown"
+}
+
+// MarshalText implements the encoding.TextMarshaler interface, so that
+// ProcessExitStatuses are represented by their names in json
+func (s ProcessExitStatus) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface, accepting
+// the names returned by String
+func (s *ProcessExitStatus) UnmarshalText(b []byte) error {
+	for _, status := range []ProcessExitStatus{ProcessUnknown, ProcessUnstarted, ProcessSuccess, ProcessFail} {
+		if status.String() == string(b) {
+			*s = status
+
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unknown process exit status %q", string(b))
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface, accepting both the
+// names returned by String, and the numbers ProcessExitStatuses were encoded as
+// before they had names, such as in DeadLetters written by older versions
+func (s *ProcessExitStatus) UnmarshalJSON(b []byte) error {
+	var name string
+
+	err := json.Unmarshal(b, &name)
+	if err == nil {
+		return s.UnmarshalText([]byte(name))
+	}
+
+	var n uint8
+
+	err = json.Unmarshal(b, &n)
+	if err != nil || ProcessExitStatus(n) > ProcessFail {
+		return fmt.Errorf("unknown process exit status %s", string(b))
+	}
+
+	*s = ProcessExitStatus(n)
+
+	return nil
+}
+
 // ProcessStatus contains various bits and pieces a process might return,
 // such as logs and statuscodes and so on
 type ProcessStatus struct {
 	Name   string
 	Logs   []string
 	Status ProcessExitStatus
+
+	// Event, when set on a successful ProcessStatus, is passed to any
+	// Processes linked to this one in place of the Event which triggered
+	// this Process
+	Event *Event
 }
 
 // Process is an interface which processes must implement
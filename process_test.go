@@ -0,0 +1,96 @@
+package orchestrator_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/dapper-data/dapper-orchestrator"
+)
+
+func TestProcessExitStatus_String(t *testing.T) {
+	for _, test := range []struct {
+		s      orchestrator.ProcessExitStatus
+		expect string
+	}{
+		{orchestrator.ProcessUnknown, "unknown"},
+		{orchestrator.ProcessUnstarted, "unstarted"},
+		{orchestrator.ProcessSuccess, "success"},
+		{orchestrator.ProcessFail, "fail"},
+		{orchestrator.ProcessExitStatus(10), "unknown"},
+	} {
+		t.Run(test.expect, func(t *testing.T) {
+			if received := test.s.String(); test.expect != received {
+				t.Errorf("expected %q, received %q", test.expect, received)
+			}
+		})
+	}
+}
+
+func TestProcessExitStatus_UnmarshalText(t *testing.T) {
+	for _, test := range []struct {
+		input       string
+		expect      orchestrator.ProcessExitStatus
+		expectError bool
+	}{
+		{"unknown", orchestrator.ProcessUnknown, false},
+		{"unstarted", orchestrator.ProcessUnstarted, false},
+		{"success", orchestrator.ProcessSuccess, false},
+		{"fail", orchestrator.ProcessFail, false},
+
+		// Error cases
+		{"failed", orchestrator.ProcessUnknown, true},
+	} {
+		t.Run(test.input, func(t *testing.T) {
+			s := new(orchestrator.ProcessExitStatus)
+
+			err := s.UnmarshalText([]byte(test.input))
+			if err == nil && test.expectError {
+				t.Errorf("expected error, received none")
+			} else if err != nil && !test.expectError {
+				t.Errorf("unexpected error %#v", err)
+			}
+
+			if test.expect != *s {
+				t.Errorf("expected %#v, received %#v", test.expect, *s)
+			}
+		})
+	}
+}
+
+func TestProcessExitStatus_UnmarshalJSON(t *testing.T) {
+	for _, test := range []struct {
+		input       string
+		expect      orchestrator.ProcessExitStatus
+		expectError bool
+	}{
+		{`"success"`, orchestrator.ProcessSuccess, false},
+		{`"fail"`, orchestrator.ProcessFail, false},
+
+		// Numbers, as ProcessExitStatuses were encoded before
+		// they had names
+		{`0`, orchestrator.ProcessUnknown, false},
+		{`2`, orchestrator.ProcessSuccess, false},
+		{`3`, orchestrator.ProcessFail, false},
+
+		// Error cases
+		{`"failed"`, orchestrator.ProcessUnknown, true},
+		{`10`, orchestrator.ProcessUnknown, true},
+		{`-1`, orchestrator.ProcessUnknown, true},
+		{`true`, orchestrator.ProcessUnknown, true},
+	} {
+		t.Run(test.input, func(t *testing.T) {
+			s := new(orchestrator.ProcessExitStatus)
+
+			err := json.Unmarshal([]byte(test.input), s)
+			if err == nil && test.expectError {
+				t.Errorf("expected error, received none")
+			} else if err != nil && !test.expectError {
+				t.Errorf("unexpected error %#v", err)
+			}
+
+			if test.expect != *s {
+				t.Errorf("expected %#v, received %#v", test.expect, *s)
+			}
+		})
+	}
+}
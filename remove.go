@@ -0,0 +1,206 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/heimdalr/dag"
+)
+
+// UnknownLinkError returns when trying to operate on a link which
+// doesn't exist
+type UnknownLinkError struct {
+	parent, child string
+}
+
+// Error returns a descriptive error message
+func (e UnknownLinkError) Error() string {
+	return fmt.Sprintf("link from %q to %q is unknown", e.parent, e.child)
+}
+
+// NewTestUnknownLinkError can be used to return a testable error (in tests)
+func NewTestUnknownLinkError(parent, child string) UnknownLinkError {
+	return UnknownLinkError{
+		parent: parent,
+		child:  child,
+	}
+}
+
+// RemoveInput stops an Input and removes it, along with its links, from the
+// Orchestrator's DAG.
+//
+// The context passed to the Input's Handle function is cancelled, and RemoveInput
+// waits for Handle to return, and then for every Event already read from the
+// Input to make its way through the DAG, until ctx is done. Where ctx is done
+// first, the Input is removed regardless, and the error from ctx returned
+func (d Orchestrator) RemoveInput(ctx context.Context, input string) (err error) {
+	i, ok := d.inputs.Load(input)
+	if !ok {
+		return UnknownInputError{input: input}
+	}
+
+	ie := i.(*inputEntry)
+	ie.remove()
+
+	// Wait for the Input to stop before removing it, so that any Event
+	// it's part way through dispatching still reaches its children
+	stopped := make(chan struct{})
+	go func() {
+		ie.running.Wait()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	d.removeVertex(input)
+	d.inputs.Delete(input)
+
+	d.logger.Info("input removed", slog.String("input", input))
+
+	if err != nil {
+		return
+	}
+
+	return ie.inFlight.wait(ctx)
+}
+
+// RemoveProcess removes a Process, along with its links, from the Orchestrator's
+// DAG, so that it's no longer triggered by anything.
+//
+// RemoveProcess waits for any Dispatch to the Process which is already in-flight,
+// including retries, to complete until ctx is done. Where ctx is done first, the
+// Process is removed regardless, with any remaining retries failing with an
+// UnknownProcessError, and the error from ctx returned
+func (d Orchestrator) RemoveProcess(ctx context.Context, process string) (err error) {
+	p, ok := d.processes.Load(process)
+	if !ok {
+		return UnknownProcessError{process: process}
+	}
+
+	d.removeVertex(process)
+
+	if pe, ok := p.(*processEntry); ok {
+		err = pe.inFlight.wait(ctx)
+	}
+
+	// Only remove the Process we were asked to; where it has been
+	// replaced in the meantime, the replacement is left alone
+	d.processes.CompareAndDelete(process, p)
+
+	d.logger.Info("process removed", slog.String("process", process))
+
+	return
+}
+
+// ReplaceProcess atomically swaps the Process with the same ID as p for p,
+// configured with opts, allowing a new version of a Process to be deployed
+// without restarting the Orchestrator or dropping Events.
+//
+// Links to and from the Process are kept, as are any partially complete joins
+// (see WithJoinPolicy). Every Dispatch from the moment ReplaceProcess is called
+// runs p, though a Dispatch which is already running completes its current
+// attempt against the old Process.
+//
+// The replacement shares the old Process' concurrency limit (see WithConcurrency),
+// taking on any limit set in opts, so that runs of both versions count towards it.
+//
+// ReplaceProcess waits for every Dispatch counted against the old Process to
+// complete, until ctx is done, after which the old Process is no longer used
+// and can be cleaned up
+func (d Orchestrator) ReplaceProcess(ctx context.Context, p Process, opts ...ProcessOption) error {
+	id := p.ID()
+
+	pe, err := d.newProcessEntry(p, opts)
+	if err != nil {
+		return err
+	}
+
+	limit := pe.limiter.limit
+
+	for {
+		old, ok := d.processes.Load(id)
+		if !ok {
+			return UnknownProcessError{process: id}
+		}
+
+		oldPE, isEntry := old.(*processEntry)
+		if isEntry {
+			oldPE.join.setPolicy(pe.join.policy)
+			pe.join = oldPE.join
+
+			oldPE.limiter.setLimit(limit)
+			pe.limiter = oldPE.limiter
+		}
+
+		if !d.processes.CompareAndSwap(id, old, pe) {
+			// Something else replaced the Process first, so
+			// try again against that
+			continue
+		}
+
+		d.logger.Info("process replaced", slog.String("process", id))
+
+		if !isEntry {
+			return nil
+		}
+
+		return oldPE.inFlight.wait(ctx)
+	}
+}
+
+// RemoveLink removes the link between parent and child, so that child is no
+// longer run when parent triggers an Event. Dispatches already in-flight
+// are unaffected
+func (d Orchestrator) RemoveLink(parent, child string) (err error) {
+	err = d.DeleteEdge(parent, child)
+	if err != nil {
+		var (
+			idErr   dag.IDUnknownError
+			edgeErr dag.EdgeUnknownError
+		)
+
+		if errors.As(err, &idErr) || errors.As(err, &edgeErr) {
+			err = UnknownLinkError{parent: parent, child: child}
+		}
+
+		return
+	}
+
+	d.links.Delete(linkKey{parent: parent, child: child})
+
+	return
+}
+
+// removeVertex removes an Input or Process from the DAG, along with the
+// configuration of any link to or from it
+func (d Orchestrator) removeVertex(id string) {
+	// The only error DeleteVertex returns is for vertices which don't
+	// exist, which is what we want anyway
+	d.DeleteVertex(id)
+
+	d.links.Range(func(k, _ any) bool {
+		if key := k.(linkKey); key.parent == id || key.child == id {
+			d.links.Delete(k)
+		}
+
+		return true
+	})
+}
+
+// loadProcess returns the processEntry for a Process, if there is one
+func (d Orchestrator) loadProcess(id string) (*processEntry, bool) {
+	p, ok := d.processes.Load(id)
+	if !ok {
+		return nil, false
+	}
+
+	pe, ok := p.(*processEntry)
+
+	return pe, ok
+}
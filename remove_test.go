@@ -0,0 +1,295 @@
+package orchestrator_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dapper-data/dapper-orchestrator"
+)
+
+// waitFor returns a channel which receives the result of f, run in the
+// background
+func waitFor(f func() error) chan error {
+	c := make(chan error, 1)
+
+	go func() {
+		c <- f()
+	}()
+
+	return c
+}
+
+func expectBlocked(t *testing.T, c chan error) {
+	t.Helper()
+
+	select {
+	case err := <-c:
+		t.Fatalf("unexpectedly returned %#v", err)
+
+	case <-time.After(time.Millisecond * 50):
+	}
+}
+
+func expectReturned(t *testing.T, c chan error, expect error) {
+	t.Helper()
+
+	select {
+	case err := <-c:
+		if !errors.Is(err, expect) {
+			t.Errorf("expected %#v, received %#v", expect, err)
+		}
+
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting to return")
+	}
+}
+
+func TestOrchestrator_RemoveInput(t *testing.T) {
+	i := newSequence("sequence-input", 1)
+	p := newGatedProcess("gated")
+
+	d := setupOrchestrator(t, testDAG{
+		process: p,
+		inputs:  []orchestrator.Input{i},
+	})
+
+	p.expectStarts(t, 1)
+
+	removed := waitFor(func() error {
+		return d.RemoveInput(context.Background(), i.ID())
+	})
+
+	// The in-flight Event should be allowed to complete
+	expectBlocked(t, removed)
+
+	close(p.release)
+	expectReturned(t, removed, nil)
+
+	if l := d.Inputs(); len(l) != 0 {
+		t.Errorf("expected no inputs, received %#v", l)
+	}
+
+	if l := d.Links(); len(l) != 0 {
+		t.Errorf("expected no links, received %#v", l)
+	}
+
+	// Inputs can be added back again, once removed
+	err := d.AddInput(context.Background(), i)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOrchestrator_RemoveInput_Timeout(t *testing.T) {
+	i := newSequence("sequence-input", 1)
+	p := newGatedProcess("gated")
+
+	d := setupOrchestrator(t, testDAG{
+		process: p,
+		inputs:  []orchestrator.Input{i},
+	})
+
+	p.expectStarts(t, 1)
+	defer close(p.release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+
+	err := d.RemoveInput(ctx, i.ID())
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected %#v, received %#v", context.DeadlineExceeded, err)
+	}
+
+	if l := d.Inputs(); len(l) != 0 {
+		t.Errorf("expected no inputs, received %#v", l)
+	}
+}
+
+func TestOrchestrator_RemoveProcess(t *testing.T) {
+	p := newGatedProcess("gated")
+	child := newRecordingProcess("child", orchestrator.ProcessSuccess)
+
+	d := setupOrchestrator(t, testDAG{
+		process:  p,
+		children: []orchestrator.Process{child},
+		inputs:   []orchestrator.Input{newSequence("sequence-input", 0)},
+	})
+
+	err := d.Trigger(p.ID(), orchestrator.Event{ID: "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p.expectStarts(t, 1)
+
+	removed := waitFor(func() error {
+		return d.RemoveProcess(context.Background(), p.ID())
+	})
+
+	expectBlocked(t, removed)
+
+	close(p.release)
+	expectReturned(t, removed, nil)
+
+	// Links were removed before the run completed, and so child isn't
+	// triggered
+	child.expectNoRun(t)
+
+	if l := d.Processes(); len(l) != 1 || l[0].ID != child.ID() {
+		t.Errorf("expected only %q, received %#v", child.ID(), l)
+	}
+
+	if l := d.Links(); len(l) != 0 {
+		t.Errorf("expected no links, received %#v", l)
+	}
+
+	expect := orchestrator.NewTestUnknownProcessError("", p.ID())
+
+	err = d.Trigger(p.ID(), orchestrator.Event{ID: "2"})
+	if !errors.Is(err, expect) {
+		t.Errorf("expected %#v, received %#v", expect, err)
+	}
+}
+
+func TestOrchestrator_ReplaceProcess(t *testing.T) {
+	v1 := newGatedProcess("process")
+	v2 := newRecordingProcess("process", orchestrator.ProcessSuccess)
+
+	d := setupOrchestrator(t, testDAG{
+		process: v1,
+		inputs:  []orchestrator.Input{newSequence("sequence-input", 0)},
+	})
+
+	err := d.Trigger(v1.ID(), orchestrator.Event{ID: "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v1.expectStarts(t, 1)
+
+	replaced := waitFor(func() error {
+		return d.ReplaceProcess(context.Background(), v2, orchestrator.WithTimeout(time.Minute))
+	})
+
+	// The new version takes new work straight away, while the old
+	// version finishes what it's doing
+	eventually(t, "process to be replaced", func() bool {
+		return d.Processes()[0].Timeout == time.Minute
+	})
+
+	err = d.Trigger(v2.ID(), orchestrator.Event{ID: "2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ev := v2.next(t)
+	if ev.ID != "2" {
+		t.Errorf("expected %q, received %q", "2", ev.ID)
+	}
+
+	expectBlocked(t, replaced)
+
+	close(v1.release)
+	expectReturned(t, replaced, nil)
+
+	if l := d.Links(); len(l) != 1 {
+		t.Errorf("expected links to be kept, received %#v", l)
+	}
+}
+
+func TestOrchestrator_ReplaceProcess_KeepsConcurrency(t *testing.T) {
+	v1 := newGatedProcess("process")
+	v2 := newGatedProcess("process")
+
+	d := setupOrchestrator(t, testDAG{
+		process:     v1,
+		processOpts: []orchestrator.ProcessOption{orchestrator.WithConcurrency(1)},
+	})
+
+	err := d.Trigger(v1.ID(), orchestrator.Event{ID: "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v1.expectStarts(t, 1)
+
+	replaced := waitFor(func() error {
+		return d.ReplaceProcess(context.Background(), v2, orchestrator.WithConcurrency(1), orchestrator.WithTimeout(time.Minute))
+	})
+
+	eventually(t, "process to be replaced", func() bool {
+		return d.Processes()[0].Timeout == time.Minute
+	})
+
+	err = d.Trigger(v2.ID(), orchestrator.Event{ID: "2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The old version's run still counts towards the limit
+	v2.expectStarts(t, 0)
+
+	close(v1.release)
+	expectReturned(t, replaced, nil)
+
+	v2.expectStarts(t, 1)
+	close(v2.release)
+}
+
+func TestOrchestrator_ReplaceProcess_KeepsJoins(t *testing.T) {
+	orders, payments := onceInput{id: "orders"}, onceInput{id: "payments"}
+	v1 := newRecordingProcess("reconciliation", orchestrator.ProcessSuccess)
+	v2 := newRecordingProcess("reconciliation", orchestrator.ProcessSuccess)
+
+	d := setupOrchestrator(t, testDAG{
+		process:     v1,
+		processOpts: []orchestrator.ProcessOption{orchestrator.WithJoinPolicy(orchestrator.JoinPolicy{Mode: orchestrator.JoinAll})},
+		inputs:      []orchestrator.Input{orders, payments},
+
+		// Hold payments back until the Process has been replaced
+		paused: []string{payments.ID()},
+	})
+
+	v1.expectNoRun(t)
+
+	err := d.ReplaceProcess(context.Background(), v2, orchestrator.WithJoinPolicy(orchestrator.JoinPolicy{Mode: orchestrator.JoinAll}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = d.ResumeInput(payments.ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ev := v2.next(t)
+	if ev.ID != "1" {
+		t.Errorf("expected event ID %q, received %q", "1", ev.ID)
+	}
+
+	v1.expectNoRun(t)
+}
+
+func TestOrchestrator_Remove_Unknown(t *testing.T) {
+	d := setupOrchestrator(t, testDAG{})
+
+	for _, test := range []struct {
+		name   string
+		f      func() error
+		expect error
+	}{
+		{"input", func() error { return d.RemoveInput(context.Background(), "nonsuch") }, orchestrator.NewTestUnknownInputError("nonsuch")},
+		{"process", func() error { return d.RemoveProcess(context.Background(), "nonsuch") }, orchestrator.NewTestUnknownProcessError("", "nonsuch")},
+		{"replace", func() error { return d.ReplaceProcess(context.Background(), new(dummyProcess)) }, orchestrator.NewTestUnknownProcessError("", "dummy-process")},
+		{"link", func() error { return d.RemoveLink("nonsuch", "nonsuch") }, orchestrator.NewTestUnknownLinkError("nonsuch", "nonsuch")},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.f()
+			if !errors.Is(err, test.expect) {
+				t.Errorf("expected %#v, received %#v", test.expect, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"strconv"
+)
+
+// MetadataAttempt is the Event Metadata key containing the attempt number,
+// starting at 1, of the current run of a Process. It is only set for Processes
+// with a RetryPolicy allowing more than one attempt; AttemptFromContext works
+// regardless
+const MetadataAttempt = "attempt"
+
+// ErrRetryable can be wrapped by errors returned from a Process to mark
+// them as retryable, such as:
+//
+//	return status, fmt.Errorf("%w: database unavailable", orchestrator.ErrRetryable)
+var ErrRetryable = errors.New("retryable error")
+
+// Retryable wraps err, marking it as retryable
+func Retryable(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return retryableError{err}
+}
+
+type retryableError struct {
+	error
+}
+
+func (retryableError) Retryable() bool {
+	return true
+}
+
+func (e retryableError) Unwrap() error {
+	return e.error
+}
+
+// IsRetryable returns true if err, or any error it wraps, either is
+// ErrRetryable, or implements:
+//
+//	interface {
+//	   Retryable() bool
+//	}
+//
+// and returns true
+func IsRetryable(err error) bool {
+	if errors.Is(err, ErrRetryable) {
+		return true
+	}
+
+	var r interface {
+		Retryable() bool
+	}
+
+	return errors.As(err, &r) && r.Retryable()
+}
+
+// RetryPolicy determines how the Orchestrator retries Processes which
+// return errors.
+//
+// The zero value runs a Process exactly once
+type RetryPolicy struct {
+	// MaxAttempts is the most number of times a Process is run for an
+	// Event, including the first attempt
+	MaxAttempts int
+
+	// Backoff determines how long to wait between attempts
+	Backoff Backoff
+
+	// Retryable returns true for errors which should be retried. When nil,
+	// IsRetryable is used
+	Retryable func(error) bool
+}
+
+func (p RetryPolicy) retry(attempt int, err error) bool {
+	if attempt >= p.MaxAttempts {
+		return false
+	}
+
+	if p.Retryable == nil {
+		return IsRetryable(err)
+	}
+
+	return p.Retryable(err)
+}
+
+type attemptKey struct{}
+
+// AttemptFromContext returns the attempt number, starting at 1, of the
+// current run of a Process, as passed to Process.Run
+func AttemptFromContext(ctx context.Context) int {
+	attempt, ok := ctx.Value(attemptKey{}).(int)
+	if !ok {
+		return 1
+	}
+
+	return attempt
+}
+
+// withAttempt sets the attempt number on ctx and, for Processes which may
+// be retried, on a copy of ev
+func withAttempt(ctx context.Context, ev Event, attempt int, policy RetryPolicy) (context.Context, Event) {
+	if policy.MaxAttempts > 1 {
+		ev.Metadata = maps.Clone(ev.Metadata)
+		if ev.Metadata == nil {
+			ev.Metadata = make(map[string]string)
+		}
+
+		ev.Metadata[MetadataAttempt] = strconv.Itoa(attempt)
+	}
+
+	return context.WithValue(ctx, attemptKey{}, attempt), ev
+}
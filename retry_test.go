@@ -0,0 +1,136 @@
+package orchestrator_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dapper-data/dapper-orchestrator"
+)
+
+// flakyProcess fails with err until it has been run succeedAfter times
+type flakyProcess struct {
+	err          error
+	succeedAfter int
+	attempts     chan [2]string
+}
+
+func newFlakyProcess(err error, succeedAfter int) *flakyProcess {
+	return &flakyProcess{
+		err:          err,
+		succeedAfter: succeedAfter,
+		attempts:     make(chan [2]string, 10),
+	}
+}
+
+func (p *flakyProcess) Run(ctx context.Context, ev orchestrator.Event) (ps orchestrator.ProcessStatus, err error) {
+	attempt := orchestrator.AttemptFromContext(ctx)
+	p.attempts <- [2]string{fmt.Sprint(attempt), ev.Metadata[orchestrator.MetadataAttempt]}
+
+	if attempt < p.succeedAfter {
+		return orchestrator.ProcessStatus{Status: orchestrator.ProcessFail}, p.err
+	}
+
+	return orchestrator.ProcessStatus{Status: orchestrator.ProcessSuccess}, nil
+}
+
+func (p *flakyProcess) ID() string {
+	return "flaky-process"
+}
+
+func TestOrchestrator_RetryPolicy(t *testing.T) {
+	oops := errors.New("oops")
+
+	for _, test := range []struct {
+		name         string
+		err          error
+		succeedAfter int
+		expectRuns   int
+		expectError  bool
+	}{
+		{"succeeds first time", orchestrator.Retryable(oops), 1, 1, false},
+		{"succeeds on retry", orchestrator.Retryable(oops), 3, 3, false},
+		{"succeeds on retry with sentinel", fmt.Errorf("%w: oops", orchestrator.ErrRetryable), 2, 2, false},
+		{"not retryable", oops, 3, 1, true},
+		{"attempts exhausted", orchestrator.Retryable(oops), 10, 4, true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			p := newFlakyProcess(test.err, test.succeedAfter)
+
+			d := setupOrchestrator(t, testDAG{
+				process: p,
+				processOpts: []orchestrator.ProcessOption{orchestrator.WithRetryPolicy(orchestrator.RetryPolicy{
+					MaxAttempts: 4,
+					Backoff:     orchestrator.Backoff{Initial: time.Millisecond},
+				})},
+				inputs: []orchestrator.Input{onceInput{id: "once-input"}},
+			})
+
+			for attempt := 1; attempt <= test.expectRuns; attempt++ {
+				select {
+				case received := <-p.attempts:
+					expect := [2]string{fmt.Sprint(attempt), fmt.Sprint(attempt)}
+					if expect != received {
+						t.Errorf("expected attempt %v, received %v", expect, received)
+					}
+
+				case <-time.After(time.Second):
+					t.Fatalf("timed out waiting for attempt %d", attempt)
+				}
+			}
+
+			select {
+			case err := <-d.ErrorChan:
+				if !test.expectError {
+					t.Errorf("unexpected error %#v", err)
+				} else if !errors.Is(err, oops) {
+					t.Errorf("expected %#v, received %#v", oops, err)
+				}
+
+			case <-time.After(time.Millisecond * 50):
+				if test.expectError {
+					t.Error("expected error, received none")
+				}
+			}
+
+			select {
+			case received := <-p.attempts:
+				t.Errorf("unexpected extra attempt %v", received)
+
+			default:
+			}
+		})
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		err    error
+		expect bool
+	}{
+		{"nil", nil, false},
+		{"plain error", errors.New("oops"), false},
+		{"Retryable", orchestrator.Retryable(errors.New("oops")), true},
+		{"wrapped Retryable", fmt.Errorf("running: %w", orchestrator.Retryable(errors.New("oops"))), true},
+		{"ErrRetryable", fmt.Errorf("%w: oops", orchestrator.ErrRetryable), true},
+		{"webhook server error", orchestrator.WebhookResponseError{StatusCode: 503}, true},
+		{"webhook rate limited", orchestrator.WebhookResponseError{StatusCode: 429}, true},
+		{"webhook client error", orchestrator.WebhookResponseError{StatusCode: 400}, false},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			received := orchestrator.IsRetryable(test.err)
+			if test.expect != received {
+				t.Errorf("expected %v, received %v", test.expect, received)
+			}
+		})
+	}
+}
+
+func TestAttemptFromContext(t *testing.T) {
+	if attempt := orchestrator.AttemptFromContext(context.Background()); attempt != 1 {
+		t.Errorf("expected 1, received %d", attempt)
+	}
+}
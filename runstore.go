@@ -0,0 +1,171 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ErrNoRunStore is returned by Orchestrator.Runs when the Orchestrator was
+// created without a RunStore (see WithRunStore)
+var ErrNoRunStore = errors.New("orchestrator: no run store configured")
+
+// Run is a record of a single attempt at running a Process
+type Run struct {
+	// ID uniquely identifies this Run
+	ID string `json:"id"`
+
+	// DispatchID is the ID of the Dispatch this Run is an attempt at,
+	// and is shared between retries
+	DispatchID string `json:"dispatch_id"`
+
+	// Input is the ID of the Input whose Event led to this Run, which is
+	// empty where the Process was triggered directly
+	Input string `json:"input"`
+
+	// Parent is the ID of the Input or Process which triggered this Run
+	Parent  string `json:"parent"`
+	Process string `json:"process"`
+	Event   Event  `json:"event"`
+
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+
+	Attempt int               `json:"attempt"`
+	Status  ProcessExitStatus `json:"status"`
+	Logs    []string          `json:"logs"`
+	Error   string            `json:"error,omitempty"`
+}
+
+// RunQuery filters the Runs returned from a RunStore, where each non-zero
+// field must match
+type RunQuery struct {
+	Process string
+	Input   string
+	EventID string
+
+	// Statuses, when set, matches Runs with any of the given statuses
+	Statuses []ProcessExitStatus
+
+	// Since and Until match Runs which started within a time range,
+	// where Since is inclusive and Until is exclusive
+	Since time.Time
+	Until time.Time
+
+	// Limit, when greater than zero, is the most Runs to return
+	Limit int
+}
+
+// Matches returns true where r matches every filter in q, ignoring Limit
+func (q RunQuery) Matches(r Run) bool {
+	switch {
+	case q.Process != "" && q.Process != r.Process,
+		q.Input != "" && q.Input != r.Input,
+		q.EventID != "" && q.EventID != r.Event.ID,
+		len(q.Statuses) > 0 && !slices.Contains(q.Statuses, r.Status),
+		!q.Since.IsZero() && r.Start.Before(q.Since),
+		!q.Until.IsZero() && !r.Start.Before(q.Until):
+		return false
+	}
+
+	return true
+}
+
+// RunStore stores Runs, and returns those matching a RunQuery, with the
+// most recently started first
+type RunStore interface {
+	SaveRun(context.Context, Run) error
+	Runs(context.Context, RunQuery) ([]Run, error)
+}
+
+// Runs returns the Runs, most recent first, matching q, such as to answer
+// whether a specific Event made it to a specific Process:
+//
+//	runs, err := d.Runs(ctx, orchestrator.RunQuery{
+//	    EventID:  "123",
+//	    Process:  "reporting",
+//	    Statuses: []orchestrator.ProcessExitStatus{orchestrator.ProcessSuccess},
+//	})
+func (d Orchestrator) Runs(ctx context.Context, q RunQuery) ([]Run, error) {
+	if d.runStore == nil {
+		return nil, ErrNoRunStore
+	}
+
+	return d.runStore.Runs(ctx, q)
+}
+
+func (d Orchestrator) saveRun(r Run) {
+	if d.runStore == nil {
+		return
+	}
+
+	err := d.runStore.SaveRun(d.processCtx, r)
+	if err != nil {
+		d.reportError(fmt.Errorf("saving run %q of %q: %w", r.ID, r.Process, err))
+	}
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	return err.Error()
+}
+
+// MemoryRunStore is a RunStore which keeps Runs in memory, and so is lost
+// when the Orchestrator stops
+type MemoryRunStore struct {
+	mutex sync.RWMutex
+	max   int
+	runs  []Run
+}
+
+// NewMemoryRunStore returns a MemoryRunStore which keeps up to max Runs,
+// discarding the oldest beyond that. Where max is zero or less, every Run
+// is kept
+func NewMemoryRunStore(max int) *MemoryRunStore {
+	return &MemoryRunStore{
+		max:  max,
+		runs: make([]Run, 0),
+	}
+}
+
+// SaveRun stores r
+func (s *MemoryRunStore) SaveRun(_ context.Context, r Run) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.runs = append(s.runs, r)
+	if s.max > 0 && len(s.runs) > s.max {
+		s.runs = slices.Delete(s.runs, 0, len(s.runs)-s.max)
+	}
+
+	return nil
+}
+
+// Runs returns the Runs matching q, most recently started first
+func (s *MemoryRunStore) Runs(_ context.Context, q RunQuery) (runs []Run, err error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	runs = make([]Run, 0)
+	for _, r := range s.runs {
+		if q.Matches(r) {
+			runs = append(runs, r)
+		}
+	}
+
+	slices.SortStableFunc(runs, func(a, b Run) int {
+		return b.Start.Compare(a.Start)
+	})
+
+	if q.Limit > 0 && len(runs) > q.Limit {
+		runs = runs[:q.Limit]
+	}
+
+	return
+}
@@ -0,0 +1,232 @@
+package orchestrator_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dapper-data/dapper-orchestrator"
+	_ "modernc.org/sqlite"
+)
+
+func newSQLiteRunStore(t *testing.T) orchestrator.RunStore {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "runs.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	s, err := orchestrator.NewSQLiteRunStore(context.Background(), db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return s
+}
+
+func TestRunStores(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	runs := []orchestrator.Run{
+		{ID: "1", DispatchID: "d1", Input: "raw", Parent: "raw", Process: "cleanse", Event: orchestrator.Event{ID: "123", Operation: orchestrator.OperationCreate}, Start: base, End: base.Add(time.Second), Attempt: 1, Status: orchestrator.ProcessSuccess, Logs: []string{"ok"}},
+		{ID: "2", DispatchID: "d2", Input: "raw", Parent: "cleanse", Process: "reporting", Event: orchestrator.Event{ID: "123"}, Start: base.Add(time.Minute), End: base.Add(time.Minute * 2), Attempt: 1, Status: orchestrator.ProcessFail, Logs: []string{}, Error: "oops"},
+		{ID: "3", DispatchID: "d2", Input: "raw", Parent: "cleanse", Process: "reporting", Event: orchestrator.Event{ID: "123"}, Start: base.Add(time.Minute * 3), End: base.Add(time.Minute * 4), Attempt: 2, Status: orchestrator.ProcessSuccess, Logs: []string{}},
+		{ID: "4", DispatchID: "d3", Input: "raw", Parent: "raw", Process: "cleanse", Event: orchestrator.Event{ID: "456"}, Start: base.Add(time.Minute * 5), End: base.Add(time.Minute * 6), Attempt: 1, Status: orchestrator.ProcessSuccess, Logs: []string{}},
+	}
+
+	for _, store := range []struct {
+		name string
+		new  func(t *testing.T) orchestrator.RunStore
+	}{
+		{"memory", func(*testing.T) orchestrator.RunStore { return orchestrator.NewMemoryRunStore(0) }},
+		{"sqlite", newSQLiteRunStore},
+	} {
+		t.Run(store.name, func(t *testing.T) {
+			s := store.new(t)
+
+			for _, r := range runs {
+				err := s.SaveRun(context.Background(), r)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			for _, test := range []struct {
+				name   string
+				q      orchestrator.RunQuery
+				expect []string
+			}{
+				{"everything", orchestrator.RunQuery{}, []string{"4", "3", "2", "1"}},
+				{"by process", orchestrator.RunQuery{Process: "reporting"}, []string{"3", "2"}},
+				{"by event", orchestrator.RunQuery{EventID: "123"}, []string{"3", "2", "1"}},
+				{"by status", orchestrator.RunQuery{Statuses: []orchestrator.ProcessExitStatus{orchestrator.ProcessFail}}, []string{"2"}},
+				{"by statuses", orchestrator.RunQuery{Statuses: []orchestrator.ProcessExitStatus{orchestrator.ProcessFail, orchestrator.ProcessSuccess}}, []string{"4", "3", "2", "1"}},
+				{"by time range", orchestrator.RunQuery{Since: base.Add(time.Minute), Until: base.Add(time.Minute * 5)}, []string{"3", "2"}},
+				{"did event 123 make it to reporting", orchestrator.RunQuery{EventID: "123", Process: "reporting", Statuses: []orchestrator.ProcessExitStatus{orchestrator.ProcessSuccess}}, []string{"3"}},
+				{"limit", orchestrator.RunQuery{Limit: 2}, []string{"4", "3"}},
+				{"no matches", orchestrator.RunQuery{Input: "cleansed"}, []string{}},
+			} {
+				t.Run(test.name, func(t *testing.T) {
+					received, err := s.Runs(context.Background(), test.q)
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					ids := make([]string, 0)
+					for _, r := range received {
+						ids = append(ids, r.ID)
+					}
+
+					if !reflect.DeepEqual(test.expect, ids) {
+						t.Errorf("expected %v, received %v", test.expect, ids)
+					}
+				})
+			}
+
+			t.Run("round trip", func(t *testing.T) {
+				received, err := s.Runs(context.Background(), orchestrator.RunQuery{Limit: 1, Until: base.Add(time.Second)})
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if len(received) != 1 {
+					t.Fatalf("expected 1 run, received %d", len(received))
+				}
+
+				r := received[0]
+				if !r.Start.Equal(runs[0].Start) || !r.End.Equal(runs[0].End) {
+					t.Errorf("expected %s - %s, received %s - %s", runs[0].Start, runs[0].End, r.Start, r.End)
+				}
+
+				r.Start, r.End = runs[0].Start, runs[0].End
+				if !reflect.DeepEqual(runs[0], r) {
+					t.Errorf("expected %#v, received %#v", runs[0], r)
+				}
+			})
+		})
+	}
+}
+
+func TestMemoryRunStore_Max(t *testing.T) {
+	s := orchestrator.NewMemoryRunStore(2)
+
+	for _, id := range []string{"1", "2", "3"} {
+		err := s.SaveRun(context.Background(), orchestrator.Run{ID: id})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	runs, err := s.Runs(context.Background(), orchestrator.RunQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(runs) != 2 || runs[0].ID != "2" || runs[1].ID != "3" {
+		t.Errorf("expected runs 2 and 3, received %#v", runs)
+	}
+}
+
+func TestOrchestrator_WithRunStore(t *testing.T) {
+	oops := errors.New("oops")
+
+	d := orchestrator.New(
+		orchestrator.WithRunStore(orchestrator.NewMemoryRunStore(0)),
+		orchestrator.WithErrorHandler(orchestrator.ErrorHandlerFunc(func(error) {})),
+	)
+
+	i := onceInput{id: "once-input"}
+	a := newRecordingProcess("a", orchestrator.ProcessSuccess)
+	a.status.Logs = []string{"hello"}
+
+	b := newFlakyProcess(orchestrator.Retryable(oops), 2)
+
+	err := d.AddProcess(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = d.AddProcess(b, orchestrator.WithRetryPolicy(orchestrator.RetryPolicy{MaxAttempts: 2, Backoff: orchestrator.Backoff{Initial: time.Millisecond}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = d.AddInput(context.Background(), i)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = d.AddLink(i, a)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = d.AddProcessLink(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a.next(t)
+	<-b.attempts
+	<-b.attempts
+
+	_, err = d.Shutdown(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	runs, err := d.Runs(context.Background(), orchestrator.RunQuery{EventID: "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(runs) != 3 {
+		t.Fatalf("expected 3 runs, received %#v", runs)
+	}
+
+	// Most recent first
+	for idx, expect := range []struct {
+		process string
+		attempt int
+		status  orchestrator.ProcessExitStatus
+		err     string
+	}{
+		{b.ID(), 2, orchestrator.ProcessSuccess, ""},
+		{b.ID(), 1, orchestrator.ProcessFail, "oops"},
+		{"a", 1, orchestrator.ProcessSuccess, ""},
+	} {
+		r := runs[idx]
+
+		if r.Process != expect.process || r.Attempt != expect.attempt || r.Status != expect.status || r.Error != expect.err {
+			t.Errorf("%d: expected %+v, received %+v", idx, expect, r)
+		}
+
+		if r.Input != "once-input" || r.ID == "" || r.DispatchID == "" || r.End.Before(r.Start) {
+			t.Errorf("%d: unexpected run %+v", idx, r)
+		}
+	}
+
+	if runs[0].DispatchID != runs[1].DispatchID {
+		t.Errorf("expected retries to share a dispatch ID, received %q and %q", runs[0].DispatchID, runs[1].DispatchID)
+	}
+
+	if !reflect.DeepEqual([]string{"hello"}, runs[2].Logs) {
+		t.Errorf("expected logs to be recorded, received %#v", runs[2].Logs)
+	}
+}
+
+func TestOrchestrator_Runs_NoRunStore(t *testing.T) {
+	_, err := orchestrator.New().Runs(context.Background(), orchestrator.RunQuery{})
+	if !errors.Is(err, orchestrator.ErrNoRunStore) {
+		t.Errorf("expected %#v, received %#v", orchestrator.ErrNoRunStore, err)
+	}
+}
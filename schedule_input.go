@@ -0,0 +1,361 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// Metadata keys set on Events created by a ScheduleInput
+const (
+	// MetadataScheduledAt is the time, in RFC3339 format, an Event
+	// was scheduled for
+	MetadataScheduledAt = "scheduled_at"
+
+	// MetadataWindowStart is the time, in RFC3339 format, of the
+	// schedule before the one an Event was scheduled for. Together with
+	// MetadataScheduledAt, this gives the window of time a Process
+	// should handle
+	MetadataWindowStart = "window_start"
+)
+
+// maxCatchUp caps the number of missed runs a ScheduleInput with a
+// CatchUp of CatchUpAll collects at once, to avoid building up huge lists
+// of runs on very frequent schedules
+const maxCatchUp = 1000
+
+func init() {
+	RegisterInput("schedule", NewScheduleInput)
+}
+
+// CatchUpPolicy determines what a ScheduleInput does with runs it missed,
+// such as while it wasn't running or while the Orchestrator was busy
+type CatchUpPolicy uint8
+
+// Supported set of CatchUpPolicies
+const (
+	// CatchUpSkip ignores missed runs
+	CatchUpSkip CatchUpPolicy = iota
+
+	// CatchUpOnce creates a single Event for the latest missed run
+	CatchUpOnce
+
+	// CatchUpAll creates an Event for every missed run
+	CatchUpAll
+)
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface
+// allowing for CatchUpPolicies to be set in config files
+func (c *CatchUpPolicy) UnmarshalText(b []byte) error {
+	switch string(b) {
+	case "skip", "":
+		*c = CatchUpSkip
+	case "once":
+		*c = CatchUpOnce
+	case "all":
+		*c = CatchUpAll
+
+	default:
+		return fmt.Errorf("Unknown catch up policy %q", string(b))
+	}
+
+	return nil
+}
+
+// Schedule returns the next time something should run, after t. Schedules
+// are created with ParseSchedule or Every
+type Schedule interface {
+	Next(t time.Time) time.Time
+}
+
+// ParseSchedule parses a standard five field cron expression (such as
+// "*/15 * * * *"), or a descriptor such as "@hourly" or "@every 15m", into
+// a Schedule.
+//
+// Expressions may be prefixed with a timezone, such as
+// "CRON_TZ=Europe/London 0 9 * * *"
+func ParseSchedule(spec string) (Schedule, error) {
+	return cron.ParseStandard(spec)
+}
+
+// Every returns a Schedule which runs every d, aligned to multiples of d
+// since the zero time, so that Every(time.Minute*15) runs on the hour, and at
+// quarter past, half past, and quarter to.
+//
+// Runs are aligned in the timezone of the time passed to Next, which for a
+// ScheduleInput is its Location, so that Every(time.Hour*24) runs at local
+// midnight. d must be positive; ScheduleInput.Handle returns an error otherwise
+func Every(d time.Duration) Schedule {
+	return interval(d)
+}
+
+type interval time.Duration
+
+func (i interval) Next(t time.Time) time.Time {
+	// Truncate works on absolute time, which lines up with UTC, so shift
+	// t by its offset in order to align to its own timezone instead
+	_, offset := t.Zone()
+	shift := time.Duration(offset) * time.Second
+
+	return t.Add(shift).Truncate(time.Duration(i)).Add(time.Duration(i)).Add(-shift)
+}
+
+// ScheduleInput is an Input which creates Events on a Schedule, allowing
+// Processes to run periodically rather than in response to something happening.
+//
+// Events are created with the Input's ID as Location, the time they were
+// scheduled for as ID, and the MetadataScheduledAt and MetadataWindowStart
+// Metadata keys set, so that a Process knows which window of time to handle
+type ScheduleInput struct {
+	name string
+
+	// Schedule determines when Events are created
+	Schedule Schedule
+
+	// Location is the timezone Schedule is evaluated in, defaulting
+	// to UTC
+	Location *time.Location
+
+	// Jitter is the largest random delay added to each run, in order
+	// to avoid lots of schedules running at once
+	Jitter time.Duration
+
+	// CatchUp determines what to do with missed runs
+	CatchUp CatchUpPolicy
+
+	// Since, when set, is treated as the time of the last run when this
+	// input starts, allowing for runs missed before starting to be caught
+	// up on
+	Since time.Time
+
+	mutex sync.Mutex
+	last  time.Time
+}
+
+// NewScheduleInput accepts an InputConfig and returns a ScheduleInput,
+// which implements the orchestrator.Input interface.
+//
+// The ConnectionString of ic is a set of URL encoded parameters, such as:
+//
+//	spec=*/15 * * * *&timezone=Europe/London&jitter=30s&catch_up=once
+//
+// Where spec is passed to ParseSchedule, timezone is an IANA timezone, jitter
+// is a duration, and catch_up is one of skip, once, or all. Only spec is required
+func NewScheduleInput(ic InputConfig) (Input, error) {
+	if ic.ID() == "" {
+		return nil, errors.New("schedule input: name must be set")
+	}
+
+	q, err := url.ParseQuery(ic.ConnectionString)
+	if err != nil {
+		return nil, fmt.Errorf("schedule input: %w", err)
+	}
+
+	s := &ScheduleInput{
+		name:     ic.ID(),
+		Location: time.UTC,
+	}
+
+	s.Schedule, err = ParseSchedule(q.Get("spec"))
+	if err != nil {
+		return nil, fmt.Errorf("schedule input: invalid spec: %w", err)
+	}
+
+	if q.Has("timezone") {
+		s.Location, err = time.LoadLocation(q.Get("timezone"))
+		if err != nil {
+			return nil, fmt.Errorf("schedule input: invalid timezone: %w", err)
+		}
+	}
+
+	if q.Has("jitter") {
+		s.Jitter, err = time.ParseDuration(q.Get("jitter"))
+		if err != nil {
+			return nil, fmt.Errorf("schedule input: invalid jitter: %w", err)
+		}
+	}
+
+	err = s.CatchUp.UnmarshalText([]byte(q.Get("catch_up")))
+	if err != nil {
+		return nil, fmt.Errorf("schedule input: %w", err)
+	}
+
+	return s, nil
+}
+
+// ID returns the ID for this Input
+func (s *ScheduleInput) ID() string {
+	return s.name
+}
+
+// Handle creates Events on Schedule until ctx is cancelled.
+//
+// The time of the last run is kept between calls to Handle, so that should
+// this input be restarted, missed runs are dealt with according to CatchUp
+func (s *ScheduleInput) Handle(ctx context.Context, c chan Event) (err error) {
+	if s.Schedule == nil {
+		return errors.New("schedule input: schedule must be set")
+	}
+
+	if i, ok := s.Schedule.(interval); ok && i <= 0 {
+		return fmt.Errorf("schedule input: interval must be positive, not %s", time.Duration(i))
+	}
+
+	last := s.lastRun()
+
+	for {
+		next := s.Schedule.Next(last.In(s.location()))
+		if !next.After(last) {
+			// Such as a cron expression which can never match
+			return fmt.Errorf("schedule input: schedule has no run after %s", last)
+		}
+
+		if next.After(time.Now()) {
+			err = s.wait(ctx, time.Until(next)+s.jitter())
+			if err != nil {
+				return
+			}
+
+			err = s.emit(ctx, c, last, next)
+			if err != nil {
+				return
+			}
+
+			last = next
+
+			continue
+		}
+
+		switch s.CatchUp {
+		case CatchUpSkip:
+			last = s.latestMissed(last)
+
+		case CatchUpOnce:
+			latest := s.latestMissed(last)
+
+			err = s.emit(ctx, c, last, latest)
+			if err != nil {
+				return
+			}
+
+			last = latest
+
+		case CatchUpAll:
+			for _, t := range s.missed(last) {
+				err = s.emit(ctx, c, last, t)
+				if err != nil {
+					return
+				}
+
+				last = t
+			}
+		}
+
+		s.setLastRun(last)
+	}
+}
+
+// missed returns every scheduled time after last which has already passed,
+// up to maxCatchUp of them
+func (s *ScheduleInput) missed(last time.Time) (missed []time.Time) {
+	now := time.Now()
+
+	missed = make([]time.Time, 0)
+	for next := s.Schedule.Next(last.In(s.location())); !next.After(now) && next.After(last) && len(missed) < maxCatchUp; next = s.Schedule.Next(next) {
+		missed = append(missed, next)
+		last = next
+	}
+
+	return
+}
+
+// latestMissed returns the most recent scheduled time after last which has
+// already passed, however many runs ago that is
+func (s *ScheduleInput) latestMissed(last time.Time) time.Time {
+	now := time.Now()
+
+	for next := s.Schedule.Next(last.In(s.location())); !next.After(now) && next.After(last); next = s.Schedule.Next(next) {
+		last = next
+	}
+
+	return last
+}
+
+func (s *ScheduleInput) emit(ctx context.Context, c chan Event, windowStart, scheduledAt time.Time) error {
+	e := Event{
+		Location: s.name,
+		ID:       scheduledAt.Format(time.RFC3339Nano),
+		Trigger:  s.name,
+		Metadata: map[string]string{
+			MetadataScheduledAt: scheduledAt.Format(time.RFC3339Nano),
+			MetadataWindowStart: windowStart.Format(time.RFC3339Nano),
+		},
+	}
+
+	select {
+	case c <- e:
+		s.setLastRun(scheduledAt)
+
+		return nil
+
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *ScheduleInput) wait(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *ScheduleInput) jitter() time.Duration {
+	if s.Jitter <= 0 {
+		return 0
+	}
+
+	return time.Duration(rand.Int63n(int64(s.Jitter)))
+}
+
+func (s *ScheduleInput) location() *time.Location {
+	if s.Location == nil {
+		return time.UTC
+	}
+
+	return s.Location
+}
+
+func (s *ScheduleInput) lastRun() time.Time {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.last.IsZero() {
+		s.last = s.Since
+	}
+
+	if s.last.IsZero() {
+		s.last = time.Now()
+	}
+
+	return s.last
+}
+
+func (s *ScheduleInput) setLastRun(t time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.last = t
+}
@@ -0,0 +1,260 @@
+package orchestrator_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dapper-data/dapper-orchestrator"
+)
+
+func TestNewScheduleInput(t *testing.T) {
+	for _, test := range []struct {
+		name            string
+		connection      string
+		expectCatchUp   orchestrator.CatchUpPolicy
+		expectJitter    time.Duration
+		expectTimezone  string
+		expectError     bool
+		expectNextAfter time.Time
+		expectNext      time.Time
+	}{
+		{"cron", "spec=*/15 * * * *", orchestrator.CatchUpSkip, 0, "UTC", false, time.Date(2023, 11, 6, 14, 6, 0, 0, time.UTC), time.Date(2023, 11, 6, 14, 15, 0, 0, time.UTC)},
+		{"descriptor", "spec=@hourly&catch_up=all", orchestrator.CatchUpAll, 0, "UTC", false, time.Date(2023, 11, 6, 14, 6, 0, 0, time.UTC), time.Date(2023, 11, 6, 15, 0, 0, 0, time.UTC)},
+		{"everything set", "spec=0 9 * * *&timezone=Europe/London&jitter=30s&catch_up=once", orchestrator.CatchUpOnce, time.Second * 30, "Europe/London", false, time.Time{}, time.Time{}},
+
+		// Error cases
+		{"missing spec", "", 0, 0, "", true, time.Time{}, time.Time{}},
+		{"invalid spec", "spec=every now and then", 0, 0, "", true, time.Time{}, time.Time{}},
+		{"invalid timezone", "spec=@hourly&timezone=Middle Earth", 0, 0, "", true, time.Time{}, time.Time{}},
+		{"invalid jitter", "spec=@hourly&jitter=loads", 0, 0, "", true, time.Time{}, time.Time{}},
+		{"invalid catch up", "spec=@hourly&catch_up=sometimes", 0, 0, "", true, time.Time{}, time.Time{}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			i, err := orchestrator.NewScheduleInput(orchestrator.InputConfig{Name: "every-so-often", ConnectionString: test.connection})
+			if err == nil && test.expectError {
+				t.Fatal("expected error, received none")
+			} else if err != nil && !test.expectError {
+				t.Fatalf("unexpected error %#v", err)
+			}
+
+			if test.expectError {
+				return
+			}
+
+			s := i.(*orchestrator.ScheduleInput)
+			if s.CatchUp != test.expectCatchUp {
+				t.Errorf("expected catch up policy %d, received %d", test.expectCatchUp, s.CatchUp)
+			}
+
+			if s.Jitter != test.expectJitter {
+				t.Errorf("expected jitter %s, received %s", test.expectJitter, s.Jitter)
+			}
+
+			if s.Location.String() != test.expectTimezone {
+				t.Errorf("expected timezone %q, received %q", test.expectTimezone, s.Location)
+			}
+
+			if !test.expectNextAfter.IsZero() {
+				next := s.Schedule.Next(test.expectNextAfter)
+				if !next.Equal(test.expectNext) {
+					t.Errorf("expected next run %s, received %s", test.expectNext, next)
+				}
+			}
+		})
+	}
+}
+
+func TestEvery(t *testing.T) {
+	s := orchestrator.Every(time.Minute * 15)
+
+	expect := time.Date(2023, 11, 6, 14, 15, 0, 0, time.UTC)
+	received := s.Next(time.Date(2023, 11, 6, 14, 6, 31, 0, time.UTC))
+
+	if !expect.Equal(received) {
+		t.Errorf("expected %s, received %s", expect, received)
+	}
+}
+
+func TestEvery_Location(t *testing.T) {
+	loc := time.FixedZone("UTC+2", 2*60*60)
+	s := orchestrator.Every(time.Hour * 24)
+
+	expect := time.Date(2023, 11, 7, 0, 0, 0, 0, loc)
+	received := s.Next(time.Date(2023, 11, 6, 23, 30, 0, 0, loc))
+
+	if !expect.Equal(received) {
+		t.Errorf("expected %s, received %s", expect, received)
+	}
+}
+
+func TestScheduleInput_Handle_InvalidSchedule(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		schedule orchestrator.Schedule
+	}{
+		{"no schedule", nil},
+		{"zero interval", orchestrator.Every(0)},
+		{"negative interval", orchestrator.Every(-time.Minute)},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			s := &orchestrator.ScheduleInput{Schedule: test.schedule}
+
+			errs := make(chan error, 1)
+			go func() {
+				errs <- s.Handle(context.Background(), make(chan orchestrator.Event))
+			}()
+
+			select {
+			case err := <-errs:
+				if err == nil {
+					t.Error("expected error, received none")
+				}
+
+			case <-time.After(time.Second):
+				t.Fatal("timed out waiting for Handle to return")
+			}
+		})
+	}
+}
+
+func TestScheduleInput_Handle(t *testing.T) {
+	s := &orchestrator.ScheduleInput{Schedule: orchestrator.Every(time.Millisecond * 20)}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := make(chan orchestrator.Event)
+	go s.Handle(ctx, c)
+
+	var previous time.Time
+	for i := 0; i < 3; i++ {
+		var ev orchestrator.Event
+
+		select {
+		case ev = <-c:
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for event")
+		}
+
+		scheduledAt, err := time.Parse(time.RFC3339Nano, ev.Metadata[orchestrator.MetadataScheduledAt])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		windowStart, err := time.Parse(time.RFC3339Nano, ev.Metadata[orchestrator.MetadataWindowStart])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !windowStart.Before(scheduledAt) {
+			t.Errorf("expected window start %s to be before %s", windowStart, scheduledAt)
+		}
+
+		if !previous.IsZero() && !previous.Equal(windowStart) {
+			t.Errorf("expected window start %s, received %s", previous, windowStart)
+		}
+
+		if ev.ID != ev.Metadata[orchestrator.MetadataScheduledAt] {
+			t.Errorf("expected ID %q, received %q", ev.Metadata[orchestrator.MetadataScheduledAt], ev.ID)
+		}
+
+		previous = scheduledAt
+	}
+}
+
+func TestScheduleInput_Handle_CatchUp(t *testing.T) {
+	for _, test := range []struct {
+		policy orchestrator.CatchUpPolicy
+		expect int
+	}{
+		{orchestrator.CatchUpSkip, 0},
+		{orchestrator.CatchUpOnce, 1},
+		{orchestrator.CatchUpAll, 5},
+	} {
+		t.Run("", func(t *testing.T) {
+			interval := time.Millisecond * 100
+
+			// Start just after a scheduled run, so that the next
+			// one doesn't happen during the test
+			time.Sleep(time.Until(time.Now().Truncate(interval).Add(interval)))
+			now := time.Now().Truncate(interval)
+
+			s := &orchestrator.ScheduleInput{
+				Schedule: orchestrator.Every(interval),
+				CatchUp:  test.policy,
+				Since:    now.Add(-interval * 5),
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			c := make(chan orchestrator.Event, 10)
+			go s.Handle(ctx, c)
+
+			// Missed runs are sent straight away, before the next
+			// scheduled run
+			time.Sleep(interval / 5)
+
+			if len(c) != test.expect {
+				t.Errorf("expected %d caught up events, received %d", test.expect, len(c))
+			}
+		})
+	}
+}
+
+func TestScheduleInput_Handle_CatchUpMany(t *testing.T) {
+	// More than a day of runs every minute is more than ScheduleInput
+	// collects at once, which shouldn't matter for skip and once
+	since := time.Now().Add(-time.Hour * 48)
+
+	for _, test := range []struct {
+		policy orchestrator.CatchUpPolicy
+		expect int
+	}{
+		{orchestrator.CatchUpSkip, 0},
+		{orchestrator.CatchUpOnce, 1},
+	} {
+		t.Run("", func(t *testing.T) {
+			s := &orchestrator.ScheduleInput{
+				Schedule: orchestrator.Every(time.Minute),
+				CatchUp:  test.policy,
+				Since:    since,
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			c := make(chan orchestrator.Event, 10)
+			go s.Handle(ctx, c)
+
+			time.Sleep(time.Millisecond * 100)
+
+			if len(c) != test.expect {
+				t.Fatalf("expected %d caught up events, received %d", test.expect, len(c))
+			}
+
+			if test.expect == 0 {
+				return
+			}
+
+			ev := <-c
+
+			scheduledAt, err := time.Parse(time.RFC3339Nano, ev.Metadata[orchestrator.MetadataScheduledAt])
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Allow for the minute having ticked over since
+			latest := time.Now().Truncate(time.Minute)
+			if !scheduledAt.Equal(latest) && !scheduledAt.Equal(latest.Add(-time.Minute)) {
+				t.Errorf("expected the latest missed run %s, received %s", latest, scheduledAt)
+			}
+
+			expectWindowStart := since.Format(time.RFC3339Nano)
+			if ev.Metadata[orchestrator.MetadataWindowStart] != expectWindowStart {
+				t.Errorf("expected window start %q, received %q", expectWindowStart, ev.Metadata[orchestrator.MetadataWindowStart])
+			}
+		})
+	}
+}
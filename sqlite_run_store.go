@@ -0,0 +1,176 @@
+package orchestrator
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const sqliteRunStoreSchema = `CREATE TABLE IF NOT EXISTS orchestrator_runs (
+    id          TEXT PRIMARY KEY,
+    dispatch_id TEXT NOT NULL,
+    input       TEXT NOT NULL,
+    parent      TEXT NOT NULL,
+    process     TEXT NOT NULL,
+    event_id    TEXT NOT NULL,
+    event       TEXT NOT NULL,
+    start_time  INTEGER NOT NULL,
+    end_time    INTEGER NOT NULL,
+    attempt     INTEGER NOT NULL,
+    status      INTEGER NOT NULL,
+    logs        TEXT NOT NULL,
+    error       TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS orchestrator_runs_process ON orchestrator_runs (process, start_time);
+CREATE INDEX IF NOT EXISTS orchestrator_runs_event_id ON orchestrator_runs (event_id, start_time);
+CREATE INDEX IF NOT EXISTS orchestrator_runs_start_time ON orchestrator_runs (start_time)`
+
+const sqliteRunStoreColumns = `id, dispatch_id, input, parent, process, event_id, event, start_time, end_time, attempt, status, logs, error`
+
+// SQLiteRunStore is a RunStore which keeps Runs in a SQLite database, in a
+// table named orchestrator_runs.
+//
+// SQLiteRunStore doesn't depend on any specific SQLite driver; callers open
+// the database with whichever they prefer, such as:
+//
+//	import _ "modernc.org/sqlite"
+//
+//	db, err := sql.Open("sqlite", "runs.db")
+//	if err != nil {
+//	    return err
+//	}
+//
+//	store, err := orchestrator.NewSQLiteRunStore(ctx, db)
+type SQLiteRunStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteRunStore creates the orchestrator_runs table in db, where it
+// doesn't already exist, and returns a SQLiteRunStore using it
+func NewSQLiteRunStore(ctx context.Context, db *sql.DB) (*SQLiteRunStore, error) {
+	_, err := db.ExecContext(ctx, sqliteRunStoreSchema)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite run store: %w", err)
+	}
+
+	return &SQLiteRunStore{db: db}, nil
+}
+
+// SaveRun stores r
+func (s *SQLiteRunStore) SaveRun(ctx context.Context, r Run) (err error) {
+	event, err := json.Marshal(r.Event)
+	if err != nil {
+		return
+	}
+
+	logs, err := json.Marshal(r.Logs)
+	if err != nil {
+		return
+	}
+
+	_, err = s.db.ExecContext(ctx, `INSERT INTO orchestrator_runs (`+sqliteRunStoreColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		r.ID, r.DispatchID, r.Input, r.Parent, r.Process, r.Event.ID, string(event),
+		r.Start.UnixNano(), r.End.UnixNano(), r.Attempt, int(r.Status), string(logs), r.Error,
+	)
+
+	return
+}
+
+// Runs returns the Runs matching q, most recently started first
+func (s *SQLiteRunStore) Runs(ctx context.Context, q RunQuery) (runs []Run, err error) {
+	query, args := q.sql()
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return
+	}
+
+	defer rows.Close()
+
+	runs = make([]Run, 0)
+	for rows.Next() {
+		var (
+			r           Run
+			eventID     string
+			event, logs string
+			start, end  int64
+			status      int
+		)
+
+		err = rows.Scan(&r.ID, &r.DispatchID, &r.Input, &r.Parent, &r.Process, &eventID, &event, &start, &end, &r.Attempt, &status, &logs, &r.Error)
+		if err != nil {
+			return
+		}
+
+		err = json.Unmarshal([]byte(event), &r.Event)
+		if err != nil {
+			return
+		}
+
+		err = json.Unmarshal([]byte(logs), &r.Logs)
+		if err != nil {
+			return
+		}
+
+		r.Start = time.Unix(0, start)
+		r.End = time.Unix(0, end)
+		r.Status = ProcessExitStatus(status)
+
+		runs = append(runs, r)
+	}
+
+	return runs, rows.Err()
+}
+
+// sql returns the SELECT statement, and its arguments, for q
+func (q RunQuery) sql() (string, []any) {
+	where := make([]string, 0)
+	args := make([]any, 0)
+
+	for _, f := range []struct {
+		column, value string
+	}{
+		{"process", q.Process},
+		{"input", q.Input},
+		{"event_id", q.EventID},
+	} {
+		if f.value != "" {
+			where = append(where, f.column+" = ?")
+			args = append(args, f.value)
+		}
+	}
+
+	if len(q.Statuses) > 0 {
+		where = append(where, "status IN (?"+strings.Repeat(", ?", len(q.Statuses)-1)+")")
+		for _, s := range q.Statuses {
+			args = append(args, int(s))
+		}
+	}
+
+	if !q.Since.IsZero() {
+		where = append(where, "start_time >= ?")
+		args = append(args, q.Since.UnixNano())
+	}
+
+	if !q.Until.IsZero() {
+		where = append(where, "start_time < ?")
+		args = append(args, q.Until.UnixNano())
+	}
+
+	query := "SELECT " + sqliteRunStoreColumns + " FROM orchestrator_runs"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+
+	query += " ORDER BY start_time DESC"
+
+	if q.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, q.Limit)
+	}
+
+	return query, args
+}
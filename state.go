@@ -0,0 +1,265 @@
+package orchestrator
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// InputState represents what the Orchestrator is doing with an Input
+type InputState int32
+
+// Provided set of InputStates
+const (
+	// InputStopped Inputs are not running, either because they have
+	// not yet been started, or because they have been told to stop
+	InputStopped InputState = iota
+
+	// InputRunning Inputs have had their Handle function called
+	InputRunning
+
+	// InputRestarting Inputs have failed, and are waiting to be restarted
+	// according to their RestartPolicy
+	InputRestarting
+
+	// InputFailed Inputs have failed more times than their RestartPolicy
+	// allows, and will not be restarted
+	InputFailed
+)
+
+// String returns the string representation of an InputState, or
+// "unknown" for any value it doesn't know about
+func (s InputState) String() string {
+	switch s {
+	case InputStopped:
+		return "stopped"
+	case InputRunning:
+		return "running"
+	case InputRestarting:
+		return "restarting"
+	case InputFailed:
+		return "failed"
+	}
+
+	return "unknown"
+}
+
+// MarshalText implements the encoding.TextMarshaler interface, so that
+// InputStates are represented by their names in json
+func (s InputState) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface, accepting
+// the names returned by String
+func (s *InputState) UnmarshalText(b []byte) error {
+	for _, state := range []InputState{InputStopped, InputRunning, InputRestarting, InputFailed} {
+		if state.String() == string(b) {
+			*s = state
+
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unknown input state %q", string(b))
+}
+
+// InputInfo describes an Input, as returned by Orchestrator.Inputs
+type InputInfo struct {
+	ID    string     `json:"id"`
+	State InputState `json:"state"`
+
+	// Paused is true where the Input has been paused with PauseInput,
+	// regardless of State
+	Paused bool `json:"paused"`
+
+	// InFlight is the number of Events from this Input still being
+	// handled by Processes
+	InFlight int64 `json:"in_flight"`
+
+	// Operations and Filtered describe the Input's operations filter
+	// (see WithOperations, and FilteredCount)
+	Operations []Operation `json:"operations"`
+	Filtered   uint64      `json:"filtered"`
+}
+
+// ProcessInfo describes a Process, as returned by Orchestrator.Processes
+type ProcessInfo struct {
+	ID string `json:"id"`
+
+	// InFlight is the number of Dispatches to this Process which have
+	// yet to complete, including those waiting on a concurrency limit,
+	// or to be retried
+	InFlight int64 `json:"in_flight"`
+
+	// LastStatus is the status of the most recent run of this Process
+	// to complete, or nil where it has yet to run
+	LastStatus *ProcessExitStatus `json:"last_status,omitempty"`
+
+	Timeout time.Duration `json:"timeout"`
+}
+
+// LinkInfo describes a link between an Input or Process, and a Process, as
+// returned by Orchestrator.Links
+type LinkInfo struct {
+	Parent string `json:"parent"`
+	Child  string `json:"child"`
+
+	// Operations and Filtered describe the link's operations filter
+	// (see WithLinkOperations, and FilteredLinkCount)
+	Operations []Operation `json:"operations"`
+	Filtered   uint64      `json:"filtered"`
+}
+
+// Inputs returns details of every Input added to the Orchestrator, ordered
+// by ID
+func (d Orchestrator) Inputs() (l []InputInfo) {
+	l = make([]InputInfo, 0)
+
+	d.inputs.Range(func(_, v any) bool {
+		ie := v.(*inputEntry)
+		paused, _ := ie.gate.state()
+
+		l = append(l, InputInfo{
+			ID:         ie.ID(),
+			State:      InputState(ie.state.Load()),
+			Paused:     paused,
+			InFlight:   ie.inFlight.count(),
+			Operations: slices.Clone(ie.operations),
+			Filtered:   ie.filtered.Load(),
+		})
+
+		return true
+	})
+
+	sort.Slice(l, func(i, j int) bool {
+		return l[i].ID < l[j].ID
+	})
+
+	return
+}
+
+// Processes returns details of every Process added to the Orchestrator,
+// ordered by ID
+func (d Orchestrator) Processes() (l []ProcessInfo) {
+	l = make([]ProcessInfo, 0)
+
+	d.processes.Range(func(_, v any) bool {
+		pe, ok := v.(*processEntry)
+		if !ok {
+			return true
+		}
+
+		l = append(l, ProcessInfo{
+			ID:         pe.ID(),
+			InFlight:   pe.inFlight.count(),
+			LastStatus: pe.lastStatus.Load(),
+			Timeout:    pe.timeout,
+		})
+
+		return true
+	})
+
+	sort.Slice(l, func(i, j int) bool {
+		return l[i].ID < l[j].ID
+	})
+
+	return
+}
+
+// Links returns details of every link in the Orchestrator, ordered by
+// parent and then child
+func (d Orchestrator) Links() (l []LinkInfo) {
+	l = make([]LinkInfo, 0)
+
+	d.links.Range(func(k, v any) bool {
+		key, le := k.(linkKey), v.(*linkEntry)
+
+		l = append(l, LinkInfo{
+			Parent:     key.parent,
+			Child:      key.child,
+			Operations: slices.Clone(le.operations),
+			Filtered:   le.filtered.Load(),
+		})
+
+		return true
+	})
+
+	sort.Slice(l, func(i, j int) bool {
+		if l[i].Parent != l[j].Parent {
+			return l[i].Parent < l[j].Parent
+		}
+
+		return l[i].Child < l[j].Child
+	})
+
+	return
+}
+
+// PauseInput stops the Orchestrator from reading Events from an Input until
+// ResumeInput is called. The Input keeps running, but blocks on sending Events,
+// which is useful for holding work back during maintenance of something a
+// Process talks to.
+//
+// Events already read from the Input carry on through the DAG
+func (d Orchestrator) PauseInput(input string) error {
+	return d.setPaused(input, true)
+}
+
+// ResumeInput resumes reading Events from an Input paused with PauseInput
+func (d Orchestrator) ResumeInput(input string) error {
+	return d.setPaused(input, false)
+}
+
+func (d Orchestrator) setPaused(input string, paused bool) error {
+	i, ok := d.inputs.Load(input)
+	if !ok {
+		return UnknownInputError{input: input}
+	}
+
+	i.(*inputEntry).gate.set(paused)
+
+	return nil
+}
+
+func (ie *inputEntry) setState(s InputState) {
+	ie.state.Store(int32(s))
+}
+
+// gate records whether an Input is paused, and signals when that changes
+type gate struct {
+	mutex   sync.Mutex
+	paused  bool
+	changed chan struct{}
+}
+
+func newGate() *gate {
+	return &gate{
+		changed: make(chan struct{}),
+	}
+}
+
+// state returns whether the gate is paused, along with a channel which is
+// closed the next time that changes
+func (g *gate) state() (bool, <-chan struct{}) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	return g.paused, g.changed
+}
+
+func (g *gate) set(paused bool) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if g.paused == paused {
+		return
+	}
+
+	g.paused = paused
+
+	close(g.changed)
+	g.changed = make(chan struct{})
+}
@@ -0,0 +1,218 @@
+package orchestrator_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dapper-data/dapper-orchestrator"
+)
+
+// eventually retries f until it returns true, or a second has passed
+func eventually(t *testing.T, msg string, f func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for !f() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", msg)
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestOrchestrator_PauseInput(t *testing.T) {
+	i := newSequence("sequence-input", 3)
+	p := newRecordingProcess("recording", orchestrator.ProcessSuccess)
+
+	d := setupOrchestrator(t, testDAG{
+		process: p,
+		inputs:  []orchestrator.Input{i},
+		paused:  []string{i.ID()},
+	})
+
+	p.expectNoRun(t)
+
+	if !d.Inputs()[0].Paused {
+		t.Errorf("expected input to be paused")
+	}
+
+	err := d.ResumeInput(i.ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Dispatches run concurrently, and so may complete in any order
+	received := make([]string, 0)
+	for j := 0; j < 3; j++ {
+		received = append(received, p.next(t).ID)
+	}
+
+	sort.Strings(received)
+
+	expect := []string{"0", "1", "2"}
+	if !reflect.DeepEqual(expect, received) {
+		t.Errorf("expected %v, received %v", expect, received)
+	}
+
+	if d.Inputs()[0].Paused {
+		t.Errorf("expected input to be resumed")
+	}
+}
+
+func TestOrchestrator_PauseInput_Unknown(t *testing.T) {
+	d := setupOrchestrator(t, testDAG{})
+
+	expect := orchestrator.NewTestUnknownInputError("nonsuch")
+
+	for _, f := range []func(string) error{d.PauseInput, d.ResumeInput} {
+		err := f("nonsuch")
+		if !errors.Is(err, expect) {
+			t.Errorf("expected %#v, received %#v", expect, err)
+		}
+	}
+}
+
+func TestOrchestrator_Inputs(t *testing.T) {
+	d := orchestrator.New(orchestrator.WithErrorHandler(orchestrator.ErrorHandlerFunc(func(error) {})))
+
+	crashing := crashingInput{runs: new(atomic.Int32), err: errors.New("oh no")}
+	sequence := newSequence("sequence-input", 1)
+	p := newGatedProcess("gated")
+
+	err := d.AddProcess(p, orchestrator.WithTimeout(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = d.AddInput(context.Background(), crashing, orchestrator.WithRestartPolicy(orchestrator.RestartPolicy{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = d.AddInput(context.Background(), sequence, orchestrator.WithOperations(orchestrator.OperationUnknown))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = d.AddLink(sequence, p, orchestrator.WithLinkOperations(orchestrator.OperationUnknown))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p.expectStarts(t, 1)
+
+	eventually(t, "crashing input to fail", func() bool {
+		return d.Inputs()[0].State == orchestrator.InputFailed
+	})
+
+	expectInputs := []orchestrator.InputInfo{
+		{ID: "crashing-input", State: orchestrator.InputFailed},
+		{ID: "sequence-input", State: orchestrator.InputRunning, InFlight: 1, Operations: []orchestrator.Operation{orchestrator.OperationUnknown}},
+	}
+
+	if received := d.Inputs(); !reflect.DeepEqual(expectInputs, received) {
+		t.Errorf("expected %#v, received %#v", expectInputs, received)
+	}
+
+	expectProcesses := []orchestrator.ProcessInfo{
+		{ID: "gated", InFlight: 1, Timeout: time.Minute},
+	}
+
+	if received := d.Processes(); !reflect.DeepEqual(expectProcesses, received) {
+		t.Errorf("expected %#v, received %#v", expectProcesses, received)
+	}
+
+	expectLinks := []orchestrator.LinkInfo{
+		{Parent: "sequence-input", Child: "gated", Operations: []orchestrator.Operation{orchestrator.OperationUnknown}},
+	}
+
+	if received := d.Links(); !reflect.DeepEqual(expectLinks, received) {
+		t.Errorf("expected %#v, received %#v", expectLinks, received)
+	}
+
+	close(p.release)
+
+	eventually(t, "in-flight counts to drop", func() bool {
+		return d.Inputs()[1].InFlight == 0 && d.Processes()[0].InFlight == 0
+	})
+
+	_, err = d.Shutdown(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	eventually(t, "sequence input to stop", func() bool {
+		return d.Inputs()[1].State == orchestrator.InputStopped
+	})
+}
+
+func TestInputState_String(t *testing.T) {
+	for _, test := range []struct {
+		s      orchestrator.InputState
+		expect string
+	}{
+		{orchestrator.InputStopped, "stopped"},
+		{orchestrator.InputRunning, "running"},
+		{orchestrator.InputRestarting, "restarting"},
+		{orchestrator.InputFailed, "failed"},
+		{orchestrator.InputState(10), "unknown"},
+	} {
+		t.Run(test.expect, func(t *testing.T) {
+			received := test.s.String()
+			if test.expect != received {
+				t.Errorf("expected %q, received %q", test.expect, received)
+			}
+		})
+	}
+}
+
+func TestOrchestrator_RemoveLink(t *testing.T) {
+	i := newSequence("sequence-input", 1)
+	p := newRecordingProcess("recording", orchestrator.ProcessSuccess)
+
+	d := setupOrchestrator(t, testDAG{
+		process: p,
+		inputs:  []orchestrator.Input{i},
+		paused:  []string{i.ID()},
+	})
+
+	err := d.RemoveLink(i.ID(), p.ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if l := d.Links(); len(l) != 0 {
+		t.Errorf("expected no links, received %#v", l)
+	}
+
+	err = d.ResumeInput(i.ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p.expectNoRun(t)
+
+	for _, test := range []struct {
+		name          string
+		parent, child string
+	}{
+		{"removed link", i.ID(), p.ID()},
+		{"unknown parent", "nonsuch", p.ID()},
+		{"unknown child", i.ID(), "nonsuch"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			expect := orchestrator.NewTestUnknownLinkError(test.parent, test.child)
+
+			err := d.RemoveLink(test.parent, test.child)
+			if !errors.Is(err, expect) {
+				t.Errorf("expected %#v, received %#v", expect, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// ErrInputExited is used as the underlying error of an InputFailedError
+// when an Input's Handle function returns nil without having been told
+// to stop
+var ErrInputExited = errors.New("input exited unexpectedly")
+
+// DefaultRestartPolicy is the RestartPolicy used for Inputs added without
+// WithRestartPolicy
+var DefaultRestartPolicy = RestartPolicy{
+	Backoff: Backoff{
+		Initial:    time.Millisecond * 100,
+		Max:        time.Second * 30,
+		Multiplier: 2,
+		Jitter:     0.2,
+	},
+	MaxRestarts: 10,
+}
+
+// RestartPolicy determines how the Orchestrator restarts Inputs whose
+// Handle function returns while the Input is still meant to be running
+type RestartPolicy struct {
+	// Backoff determines how long to wait between restarts
+	Backoff Backoff
+
+	// MaxRestarts is the number of times an Input is restarted before the
+	// Orchestrator gives up on it. A negative value restarts forever
+	MaxRestarts int
+}
+
+// InputFailedError is sent to an Orchestrator's ErrorChan whenever an Input's
+// Handle function returns while the Input is still meant to be running
+type InputFailedError struct {
+	// Input is the ID of the Input which failed
+	Input string
+
+	// Failures is the number of times in a row this Input has failed,
+	// including this failure. Runs which last longer than the backoff
+	// before them reset the count
+	Failures int
+
+	// Final is true when the Orchestrator has given up on this Input,
+	// and will not restart it again
+	Final bool
+
+	// Err is the error returned by the Input, or ErrInputExited
+	Err error
+}
+
+// Error returns a descriptive error message
+func (e InputFailedError) Error() string {
+	action := "restarting"
+	if e.Final {
+		action = "giving up"
+	}
+
+	return fmt.Sprintf("input %q failed (failure %d, %s): %s", e.Input, e.Failures, action, e.Err)
+}
+
+// Unwrap returns the error returned by the Input
+func (e InputFailedError) Unwrap() error {
+	return e.Err
+}
+
+// superviseInput runs an Input's Handle function, restarting it according
+// to policy until either ctx is cancelled, or the Input fails too many times,
+// at which point cancel is called to stop routing events from it
+func (d Orchestrator) superviseInput(ctx context.Context, cancel context.CancelFunc, ie *inputEntry, c chan Event) {
+	defer cancel()
+
+	i, policy := ie.Input, ie.restartPolicy
+	logger := d.logger.With(slog.String("input", i.ID()))
+
+	var failures int
+	for {
+		ie.setState(InputRunning)
+		logger.Info("input started", slog.Int("failures", failures))
+
+		started := d.clock.Now()
+		err := i.Handle(ctx, c)
+
+		// Inputs returning because we've told them to stop are
+		// behaving correctly
+		if ctx.Err() != nil {
+			ie.setState(InputStopped)
+			logger.Info("input stopped")
+
+			return
+		}
+
+		if err == nil {
+			err = ErrInputExited
+		}
+
+		// Inputs which fail now and again, having run healthily
+		// in between, shouldn't build up to MaxRestarts
+		if failures > 0 && d.clock.Now().Sub(started) > policy.Backoff.limit(failures-1) {
+			failures = 0
+		}
+
+		failures++
+
+		final := policy.MaxRestarts >= 0 && failures > policy.MaxRestarts
+		d.reportError(InputFailedError{
+			Input:    i.ID(),
+			Failures: failures,
+			Final:    final,
+			Err:      err,
+		})
+
+		if final {
+			ie.setState(InputFailed)
+			logger.Error("input failed too many times, giving up", slog.Int("failures", failures))
+
+			return
+		}
+
+		ie.setState(InputRestarting)
+
+		select {
+		case <-ctx.Done():
+			ie.setState(InputStopped)
+
+			return
+
+		case <-d.clock.After(policy.Backoff.Duration(failures - 1)):
+		}
+	}
+}
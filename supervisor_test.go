@@ -0,0 +1,188 @@
+package orchestrator_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dapper-data/dapper-orchestrator"
+)
+
+// crashingInput fails immediately, every time it's run
+type crashingInput struct {
+	runs *atomic.Int32
+	err  error
+}
+
+func (i crashingInput) Handle(context.Context, chan orchestrator.Event) error {
+	i.runs.Add(1)
+
+	return i.err
+}
+
+func (crashingInput) ID() string {
+	return "crashing-input"
+}
+
+func TestOrchestrator_AddInput_Restarts(t *testing.T) {
+	for _, test := range []struct {
+		name        string
+		err         error
+		expectError error
+	}{
+		{"returns error", errors.New("oh no"), nil},
+		{"returns nil", nil, orchestrator.ErrInputExited},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if test.expectError == nil {
+				test.expectError = test.err
+			}
+
+			d := orchestrator.New()
+			i := crashingInput{runs: new(atomic.Int32), err: test.err}
+
+			err := d.AddInput(context.Background(), i, orchestrator.WithRestartPolicy(orchestrator.RestartPolicy{
+				Backoff:     orchestrator.Backoff{Initial: time.Millisecond},
+				MaxRestarts: 2,
+			}))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for failure := 1; failure <= 3; failure++ {
+				var ife orchestrator.InputFailedError
+
+				select {
+				case err = <-d.ErrorChan:
+				case <-time.After(time.Second):
+					t.Fatalf("timed out waiting for failure %d", failure)
+				}
+
+				if !errors.As(err, &ife) {
+					t.Fatalf("expected orchestrator.InputFailedError, received %#v", err)
+				}
+
+				if !errors.Is(err, test.expectError) {
+					t.Errorf("expected %#v, received %#v", test.expectError, ife.Err)
+				}
+
+				if ife.Failures != failure {
+					t.Errorf("expected failure %d, received %d", failure, ife.Failures)
+				}
+
+				if ife.Final != (failure == 3) {
+					t.Errorf("unexpected value for Final on failure %d: %v", failure, ife.Final)
+				}
+			}
+
+			select {
+			case err = <-d.ErrorChan:
+				t.Errorf("unexpected error %#v", err)
+
+			case <-time.After(time.Millisecond * 50):
+			}
+
+			if i.runs.Load() != 3 {
+				t.Errorf("expected 3 runs, received %d", i.runs.Load())
+			}
+		})
+	}
+}
+
+// steppingClock moves forward by step each time it's asked the time, and
+// never waits
+type steppingClock struct {
+	mutex sync.Mutex
+	now   time.Time
+	step  time.Duration
+}
+
+func (c *steppingClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.now = c.now.Add(c.step)
+
+	return c.now
+}
+
+func (c *steppingClock) After(time.Duration) <-chan time.Time {
+	ch := make(chan time.Time, 1)
+	ch <- c.Now()
+
+	return ch
+}
+
+func TestOrchestrator_AddInput_ResetsFailures(t *testing.T) {
+	// Every run appears to last at least a minute, which is longer than
+	// the backoff, and so the Input is never given up on
+	d := setupOrchestrator(t, testDAG{
+		opts: []orchestrator.Option{orchestrator.WithClock(&steppingClock{now: time.Now(), step: time.Minute})},
+	})
+
+	err := d.AddInput(context.Background(), crashingInput{runs: new(atomic.Int32), err: errors.New("oh no")}, orchestrator.WithRestartPolicy(orchestrator.RestartPolicy{
+		Backoff:     orchestrator.Backoff{Initial: time.Second},
+		MaxRestarts: 2,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for failure := 1; failure <= 5; failure++ {
+		var ife orchestrator.InputFailedError
+
+		select {
+		case err = <-d.ErrorChan:
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for failure %d", failure)
+		}
+
+		if !errors.As(err, &ife) {
+			t.Fatalf("expected orchestrator.InputFailedError, received %#v", err)
+		}
+
+		if ife.Failures != 1 || ife.Final {
+			t.Errorf("expected failure 1, which isn't final, received %d, %v", ife.Failures, ife.Final)
+		}
+	}
+}
+
+func TestOrchestrator_AddInput_CleanStop(t *testing.T) {
+	d := orchestrator.New()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	err := d.AddInput(ctx, onceInput{id: "once-input"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cancel()
+
+	select {
+	case err = <-d.ErrorChan:
+		t.Errorf("unexpected error %#v", err)
+
+	case <-time.After(time.Millisecond * 50):
+	}
+}
+
+func TestInputFailedError_Error(t *testing.T) {
+	for _, test := range []struct {
+		err    orchestrator.InputFailedError
+		expect string
+	}{
+		{orchestrator.InputFailedError{Input: "dummy-input", Failures: 1, Err: orchestrator.ErrInputExited}, `input "dummy-input" failed (failure 1, restarting): input exited unexpectedly`},
+		{orchestrator.InputFailedError{Input: "dummy-input", Failures: 3, Final: true, Err: orchestrator.ErrInputExited}, `input "dummy-input" failed (failure 3, giving up): input exited unexpectedly`},
+	} {
+		t.Run(test.expect, func(t *testing.T) {
+			received := test.err.Error()
+			if test.expect != received {
+				t.Errorf("expected\n%s\nreceived\n%s", test.expect, received)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ProcessTimeoutError is returned when a Process takes longer than the
+// timeout set with WithTimeout (or ProcessConfig.Timeout)
+type ProcessTimeoutError struct {
+	Process string
+	Timeout time.Duration
+}
+
+// Error returns a descriptive error message
+func (e ProcessTimeoutError) Error() string {
+	return fmt.Sprintf("process %q timed out after %s", e.Process, e.Timeout)
+}
+
+// Retryable returns true; a Process which timed out may well complete
+// when tried again (see RetryPolicy)
+func (e ProcessTimeoutError) Retryable() bool {
+	return true
+}
+
+// Is allows for ProcessTimeoutErrors to be matched against
+// context.DeadlineExceeded with errors.Is
+func (e ProcessTimeoutError) Is(target error) bool {
+	return target == context.DeadlineExceeded
+}
+
+type runResult struct {
+	status ProcessStatus
+	err    error
+}
+
+// run runs the Process with ev, bounded by the Process' timeout.
+//
+// Where the timeout fires, run returns straight away, even if the Process
+// ignores its context, so that a hung Process doesn't hold on to its slot
+// forever
+func (pe *processEntry) run(ctx context.Context, ev Event) (status ProcessStatus, err error) {
+	if pe.timeout <= 0 {
+		return pe.Run(ctx, ev)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, pe.timeout)
+	defer cancel()
+
+	results := make(chan runResult, 1)
+	go func() {
+		var r runResult
+
+		r.status, r.err = pe.Run(ctx, ev)
+		results <- r
+	}()
+
+	select {
+	case r := <-results:
+		status, err = r.status, r.err
+		if err == nil || !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return
+		}
+
+	case <-ctx.Done():
+		if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			// Our parent context was cancelled, such as on shutdown,
+			// so wait on the Process to notice
+			r := <-results
+
+			return r.status, r.err
+		}
+
+		status = ProcessStatus{Name: pe.ID()}
+	}
+
+	status.Status = ProcessFail
+	err = ProcessTimeoutError{
+		Process: pe.ID(),
+		Timeout: pe.timeout,
+	}
+
+	return
+}
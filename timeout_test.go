@@ -0,0 +1,98 @@
+package orchestrator_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dapper-data/dapper-orchestrator"
+)
+
+// slowProcess takes delay to run, optionally ignoring its context
+type slowProcess struct {
+	delay     time.Duration
+	ignoreCtx bool
+}
+
+func (p slowProcess) Run(ctx context.Context, _ orchestrator.Event) (orchestrator.ProcessStatus, error) {
+	t := time.NewTimer(p.delay)
+	defer t.Stop()
+
+	if p.ignoreCtx {
+		<-t.C
+	} else {
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return orchestrator.ProcessStatus{Name: p.ID(), Status: orchestrator.ProcessFail}, ctx.Err()
+		}
+	}
+
+	return orchestrator.ProcessStatus{Name: p.ID(), Status: orchestrator.ProcessSuccess}, nil
+}
+
+func (p slowProcess) ID() string {
+	return "slow-process"
+}
+
+func TestOrchestrator_WithTimeout(t *testing.T) {
+	for _, test := range []struct {
+		name        string
+		p           slowProcess
+		expectError bool
+	}{
+		{"completes in time", slowProcess{delay: time.Millisecond}, false},
+		{"respects context", slowProcess{delay: time.Second * 10}, true},
+		{"ignores context", slowProcess{delay: time.Second * 10, ignoreCtx: true}, true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			child := newRecordingProcess("child", orchestrator.ProcessSuccess)
+
+			d := setupOrchestrator(t, testDAG{
+				process:     test.p,
+				processOpts: []orchestrator.ProcessOption{orchestrator.WithTimeout(time.Millisecond * 50)},
+				children:    []orchestrator.Process{child},
+				inputs:      []orchestrator.Input{onceInput{id: "once-input"}},
+			})
+
+			if !test.expectError {
+				child.next(t)
+
+				return
+			}
+
+			select {
+			case err := <-d.ErrorChan:
+				expect := orchestrator.ProcessTimeoutError{Process: test.p.ID(), Timeout: time.Millisecond * 50}
+
+				var pte orchestrator.ProcessTimeoutError
+				if !errors.As(err, &pte) {
+					t.Fatalf("expected %#v, received %#v", expect, err)
+				}
+
+				if expect != pte {
+					t.Errorf("expected %#v, received %#v", expect, pte)
+				}
+
+				if !errors.Is(err, context.DeadlineExceeded) {
+					t.Error("expected error to be context.DeadlineExceeded")
+				}
+
+			case <-time.After(time.Second):
+				t.Fatal("timed out waiting for error")
+			}
+
+			child.expectNoRun(t)
+		})
+	}
+}
+
+func TestProcessTimeoutError_Error(t *testing.T) {
+	expect := `process "slow" timed out after 5s`
+	received := orchestrator.ProcessTimeoutError{Process: "slow", Timeout: time.Second * 5}.Error()
+
+	if expect != received {
+		t.Errorf("expected %q, received %q", expect, received)
+	}
+}
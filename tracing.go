@@ -0,0 +1,98 @@
+package orchestrator
+
+import (
+	"context"
+	"maps"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Metadata keys used to propagate trace context between Processes, in W3C
+// Trace Context format (see WithTracerProvider)
+const (
+	MetadataTraceParent = "traceparent"
+	MetadataTraceState  = "tracestate"
+)
+
+// tracerName is the name of the instrumentation scope spans are
+// created with
+const tracerName = "github.com/dapper-data/dapper-orchestrator"
+
+// Attributes set on spans
+const (
+	attrInput      = attribute.Key("orchestrator.input")
+	attrParent     = attribute.Key("orchestrator.parent")
+	attrProcess    = attribute.Key("orchestrator.process")
+	attrDispatchID = attribute.Key("orchestrator.dispatch.id")
+	attrEventID    = attribute.Key("orchestrator.event.id")
+	attrOperation  = attribute.Key("orchestrator.event.operation")
+	attrLocation   = attribute.Key("orchestrator.event.location")
+	attrAttempt    = attribute.Key("orchestrator.attempt")
+	attrStatus     = attribute.Key("orchestrator.status")
+)
+
+// traceContext returns a context containing the span context carried in
+// e's Metadata, if any
+func (d Orchestrator) traceContext(ctx context.Context, e Event) context.Context {
+	if e.Metadata == nil {
+		return ctx
+	}
+
+	return d.propagator.Extract(ctx, propagation.MapCarrier(e.Metadata))
+}
+
+// withTraceContext returns e, with the span context in ctx added to its
+// Metadata. e is returned as-is where ctx contains no span
+func (d Orchestrator) withTraceContext(ctx context.Context, e Event) Event {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return e
+	}
+
+	carrier := make(propagation.MapCarrier)
+	d.propagator.Inject(ctx, carrier)
+
+	if len(carrier) == 0 {
+		return e
+	}
+
+	md := maps.Clone(e.Metadata)
+	if md == nil {
+		md = make(map[string]string)
+	}
+
+	maps.Copy(md, carrier)
+	e.Metadata = md
+
+	return e
+}
+
+// traceEmit starts, and ends, a span for an Event emitted by an Input,
+// continuing any trace the Event already carries, and returns the Event
+// carrying the new span
+func (d Orchestrator) traceEmit(input string, e Event) Event {
+	ctx, span := d.tracer.Start(d.traceContext(context.Background(), e), "emit "+input,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attrInput.String(input),
+			attrEventID.String(e.ID),
+			attrOperation.String(e.Operation.String()),
+			attrLocation.String(e.Location),
+		),
+	)
+	defer span.End()
+
+	return d.withTraceContext(ctx, e)
+}
+
+// endSpan records err, where set, and ends span
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
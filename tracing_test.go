@@ -0,0 +1,138 @@
+package orchestrator_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/dapper-data/dapper-orchestrator"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracedProcess records the span context it is run with, along with the
+// trace context in the Event it is run with
+type tracedProcess struct {
+	id    string
+	calls chan tracedCall
+}
+
+type tracedCall struct {
+	span        trace.SpanContext
+	traceParent string
+}
+
+func newTracedProcess(id string) *tracedProcess {
+	return &tracedProcess{
+		id:    id,
+		calls: make(chan tracedCall, 10),
+	}
+}
+
+func (p *tracedProcess) Run(ctx context.Context, ev orchestrator.Event) (orchestrator.ProcessStatus, error) {
+	p.calls <- tracedCall{
+		span:        trace.SpanContextFromContext(ctx),
+		traceParent: ev.Metadata[orchestrator.MetadataTraceParent],
+	}
+
+	return orchestrator.ProcessStatus{Name: p.id, Status: orchestrator.ProcessSuccess}, nil
+}
+
+func (p *tracedProcess) ID() string {
+	return p.id
+}
+
+func TestOrchestrator_WithTracerProvider(t *testing.T) {
+	for _, test := range []struct {
+		name        string
+		traceParent string
+	}{
+		{"new trace", ""},
+		{"continued trace", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			exporter := tracetest.NewInMemoryExporter()
+			tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+			ev := orchestrator.Event{ID: "1"}
+			if test.traceParent != "" {
+				ev.Metadata = map[string]string{orchestrator.MetadataTraceParent: test.traceParent}
+			}
+
+			a := newTracedProcess("a")
+			b := newTracedProcess("b")
+
+			d := setupOrchestrator(t, testDAG{
+				opts:     []orchestrator.Option{orchestrator.WithTracerProvider(tp)},
+				process:  a,
+				children: []orchestrator.Process{b},
+				inputs:   []orchestrator.Input{sequenceInput{id: "sequence-input", events: []orchestrator.Event{ev}}},
+			})
+
+			aCall := <-a.calls
+			<-b.calls
+
+			_, err := d.Shutdown(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			spans := make(map[string]tracetest.SpanStub)
+			for _, s := range exporter.GetSpans() {
+				spans[s.Name] = s
+			}
+
+			for _, name := range []string{"emit sequence-input", "dispatch a", "wait a", "run a", "dispatch b", "wait b", "run b"} {
+				if _, ok := spans[name]; !ok {
+					t.Fatalf("expected span %q, received %v", name, spans)
+				}
+			}
+
+			for child, parent := range map[string]string{
+				"dispatch a": "emit sequence-input",
+				"wait a":     "dispatch a",
+				"run a":      "dispatch a",
+				"dispatch b": "dispatch a",
+				"wait b":     "dispatch b",
+				"run b":      "dispatch b",
+			} {
+				expect := spans[parent].SpanContext.SpanID()
+				received := spans[child].Parent.SpanID()
+
+				if expect != received {
+					t.Errorf("%s: expected parent %s (%s), received %s", child, parent, expect, received)
+				}
+			}
+
+			traceID := spans["emit sequence-input"].SpanContext.TraceID()
+			if test.traceParent != "" && !strings.Contains(test.traceParent, traceID.String()) {
+				t.Errorf("expected trace %s to be continued, received %s", test.traceParent, traceID)
+			}
+
+			runA := spans["run a"].SpanContext
+			if aCall.span.SpanID() != runA.SpanID() || aCall.span.TraceID() != traceID {
+				t.Errorf("expected run context to carry span %s, received %s", runA.SpanID(), aCall.span.SpanID())
+			}
+
+			if !strings.Contains(aCall.traceParent, runA.SpanID().String()) {
+				t.Errorf("expected event metadata to carry span %s, received %q", runA.SpanID(), aCall.traceParent)
+			}
+		})
+	}
+}
+
+func TestOrchestrator_NoTracing(t *testing.T) {
+	// Without a TracerProvider, Events are left untouched
+	p := newRecordingProcess("recording", orchestrator.ProcessSuccess)
+
+	setupOrchestrator(t, testDAG{
+		process: p,
+		inputs:  []orchestrator.Input{onceInput{id: "once-input"}},
+	})
+
+	ev := p.next(t)
+	if ev.Metadata != nil {
+		t.Errorf("expected no metadata, received %#v", ev.Metadata)
+	}
+}
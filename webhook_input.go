@@ -0,0 +1,384 @@
+package orchestrator
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Defaults used by NewWebhookInput
+const (
+	DefaultWebhookSignatureHeader = "X-Signature-256"
+	DefaultWebhookMaxBodySize     = 1 << 20
+	DefaultWebhookAcceptTimeout   = time.Second * 5
+)
+
+func init() {
+	RegisterInput("webhook", NewWebhookInput)
+}
+
+// WebhookInput is an Input which accepts Events as JSON, in the same shape
+// as Event.JSON produces, POSTed over HTTP.
+//
+// WebhookInput responds with 202 Accepted once an Event has been accepted by
+// the Orchestrator, or 503 Service Unavailable where the Orchestrator does not
+// accept the Event within AcceptTimeout (or where the input isn't running).
+//
+// By default, WebhookInput serves requests at Path on Addr, sharing an HTTP
+// server with any other WebhookInputs on the same Addr, so long as their Paths
+// differ. Where Mux is set, WebhookInput instead registers itself on Mux at Path. WebhookInput is also an
+// http.Handler, and so can be mounted anywhere else, too.
+//
+// When Secret is set, requests must be signed with a hex encoded HMAC-SHA256 of
+// the request body, keyed with Secret, in the SignatureHeader header, such as:
+//
+//	X-Signature-256: sha256=0bd6f0bd...
+type WebhookInput struct {
+	name string
+
+	// Addr is the address to listen on when Mux is nil
+	Addr string
+
+	// Mux, when set, is used to serve requests instead of listening
+	// on Addr
+	Mux *http.ServeMux
+
+	// Path is the path to serve requests on
+	Path string
+
+	// Secret, when set, is used to verify request signatures
+	Secret []byte
+
+	// SignatureHeader is the header which contains request signatures
+	SignatureHeader string
+
+	// MaxBodySize is the largest request body, in bytes, accepted
+	MaxBodySize int64
+
+	// AcceptTimeout is how long to wait for the Orchestrator to accept
+	// an Event before responding with 503 Service Unavailable
+	AcceptTimeout time.Duration
+
+	register sync.Once
+	mutex    sync.RWMutex
+	events   chan Event
+	ctx      context.Context
+}
+
+// NewWebhookInput accepts an InputConfig and returns a WebhookInput,
+// which implements the orchestrator.Input interface.
+//
+// The ConnectionString of ic is a URL of the form:
+//
+//	http://0.0.0.0:8080/some/path?secret=s3cr3t&max_body_size=1024
+//
+// Where the host and port become Addr, and the path becomes Path (defaulting
+// to /<input name>). The secret and max_body_size parameters are optional
+func NewWebhookInput(ic InputConfig) (Input, error) {
+	if ic.ID() == "" {
+		return nil, errors.New("webhook input: name must be set")
+	}
+
+	u, err := url.Parse(ic.ConnectionString)
+	if err != nil {
+		return nil, fmt.Errorf("webhook input: %w", err)
+	}
+
+	w := &WebhookInput{
+		name:            ic.ID(),
+		Addr:            u.Host,
+		Path:            u.Path,
+		SignatureHeader: DefaultWebhookSignatureHeader,
+		MaxBodySize:     DefaultWebhookMaxBodySize,
+		AcceptTimeout:   DefaultWebhookAcceptTimeout,
+	}
+
+	if w.Path == "" {
+		w.Path = "/" + ic.ID()
+	}
+
+	q := u.Query()
+	if q.Has("secret") {
+		w.Secret = []byte(q.Get("secret"))
+	}
+
+	if q.Has("max_body_size") {
+		w.MaxBodySize, err = strconv.ParseInt(q.Get("max_body_size"), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("webhook input: invalid max_body_size: %w", err)
+		}
+	}
+
+	return w, nil
+}
+
+// ID returns the ID for this Input
+func (w *WebhookInput) ID() string {
+	return w.name
+}
+
+// Handle serves requests, either by listening on Addr, or by registering on
+// Mux, until ctx is cancelled
+func (w *WebhookInput) Handle(ctx context.Context, c chan Event) (err error) {
+	w.mutex.Lock()
+	w.events = c
+	w.ctx = ctx
+	w.mutex.Unlock()
+
+	defer func() {
+		w.mutex.Lock()
+		w.events = nil
+		w.mutex.Unlock()
+	}()
+
+	if w.Mux != nil {
+		// ServeMux doesn't allow handlers to be removed, so
+		// register once, and rely on w.events being nil to
+		// know when we're not running
+		w.register.Do(func() {
+			w.Mux.Handle(w.Path, w)
+		})
+
+		<-ctx.Done()
+
+		return ctx.Err()
+	}
+
+	s, err := listenWebhook(w)
+	if err != nil {
+		return
+	}
+
+	defer s.release(w)
+
+	select {
+	case <-s.done:
+		return s.err
+
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// webhookServer is an HTTP server shared by every WebhookInput listening on
+// the same Addr, which routes requests to each by Path
+type webhookServer struct {
+	server *http.Server
+	mux    atomic.Pointer[http.ServeMux]
+
+	// handlers is guarded by webhookServers.mutex
+	handlers map[string]http.Handler
+
+	// done is closed once server stops, with err set to the reason why
+	done chan struct{}
+	err  error
+}
+
+// webhookServers contains the running webhookServers, by Addr
+var webhookServers = struct {
+	mutex   sync.Mutex
+	servers map[string]*webhookServer
+}{
+	servers: make(map[string]*webhookServer),
+}
+
+// listenWebhook registers w with the webhookServer for its Addr, starting
+// one where there isn't already one running
+func listenWebhook(w *WebhookInput) (*webhookServer, error) {
+	webhookServers.mutex.Lock()
+	defer webhookServers.mutex.Unlock()
+
+	s, ok := webhookServers.servers[w.Addr]
+	if ok {
+		select {
+		case <-s.done:
+			// The server has failed, and so is replaced
+			ok = false
+
+		default:
+		}
+	}
+
+	if !ok {
+		s = &webhookServer{
+			handlers: make(map[string]http.Handler),
+			done:     make(chan struct{}),
+		}
+
+		s.server = &http.Server{
+			Addr:              w.Addr,
+			Handler:           s,
+			ReadHeaderTimeout: time.Second * 10,
+		}
+
+		s.rebuild()
+		webhookServers.servers[w.Addr] = s
+
+		go func() {
+			s.err = s.server.ListenAndServe()
+			close(s.done)
+		}()
+	}
+
+	if _, ok := s.handlers[w.Path]; ok {
+		return nil, fmt.Errorf("webhook input %q: path %s is already served on %s", w.name, w.Path, w.Addr)
+	}
+
+	s.handlers[w.Path] = w
+	s.rebuild()
+
+	return s, nil
+}
+
+// release unregisters w, shutting the server down once no other WebhookInput
+// is using it
+func (s *webhookServer) release(w *WebhookInput) {
+	webhookServers.mutex.Lock()
+
+	delete(s.handlers, w.Path)
+	s.rebuild()
+
+	if len(s.handlers) > 0 {
+		webhookServers.mutex.Unlock()
+
+		return
+	}
+
+	if webhookServers.servers[w.Addr] == s {
+		delete(webhookServers.servers, w.Addr)
+	}
+
+	webhookServers.mutex.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	s.server.Shutdown(ctx)
+}
+
+// rebuild replaces the ServeMux requests are routed by with one containing
+// the current handlers, since ServeMux doesn't allow handlers to be removed
+func (s *webhookServer) rebuild() {
+	mux := http.NewServeMux()
+	for path, h := range s.handlers {
+		mux.Handle(path, h)
+	}
+
+	s.mux.Store(mux)
+}
+
+// ServeHTTP implements the http.Handler interface
+func (s *webhookServer) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	s.mux.Load().ServeHTTP(rw, r)
+}
+
+// ServeHTTP implements the http.Handler interface, turning requests into
+// Events
+func (w *WebhookInput) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		rw.Header().Set("Allow", http.MethodPost)
+		http.Error(rw, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	body, err := readBody(rw, r, w.MaxBodySize)
+	if err != nil {
+		var mbe *http.MaxBytesError
+		if errors.As(err, &mbe) {
+			http.Error(rw, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+
+			return
+		}
+
+		http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+
+		return
+	}
+
+	if len(w.Secret) > 0 && !w.verify(r.Header.Get(w.SignatureHeader), body) {
+		http.Error(rw, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+
+		return
+	}
+
+	var e Event
+
+	err = json.Unmarshal(body, &e)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	e.Trigger = w.name
+
+	w.mutex.RLock()
+	events, ctx := w.events, w.ctx
+	w.mutex.RUnlock()
+
+	if events == nil {
+		http.Error(rw, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+
+		return
+	}
+
+	timer := time.NewTimer(w.AcceptTimeout)
+	defer timer.Stop()
+
+	select {
+	case events <- e:
+		rw.WriteHeader(http.StatusAccepted)
+
+	case <-timer.C:
+		http.Error(rw, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+
+	case <-ctx.Done():
+		http.Error(rw, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+
+	case <-r.Context().Done():
+	}
+}
+
+// verify checks signature is a valid HMAC-SHA256 of body
+func (w *WebhookInput) verify(signature string, body []byte) bool {
+	signature, ok := strings.CutPrefix(signature, "sha256=")
+	if !ok {
+		return false
+	}
+
+	received, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, w.Secret)
+	mac.Write(body)
+
+	return hmac.Equal(received, mac.Sum(nil))
+}
+
+// readBody reads a request body, up to max bytes. A max of zero or less
+// reads the whole body
+func readBody(rw http.ResponseWriter, r *http.Request, max int64) ([]byte, error) {
+	body := r.Body
+	if max > 0 {
+		body = http.MaxBytesReader(rw, r.Body, max)
+	}
+
+	defer body.Close()
+
+	return io.ReadAll(body)
+}
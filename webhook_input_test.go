@@ -0,0 +1,269 @@
+package orchestrator_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dapper-data/dapper-orchestrator"
+)
+
+func sign(secret, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestNewWebhookInput(t *testing.T) {
+	for _, test := range []struct {
+		name        string
+		ic          orchestrator.InputConfig
+		expectAddr  string
+		expectPath  string
+		expectSize  int64
+		expectError bool
+	}{
+		{"defaults", orchestrator.InputConfig{Name: "hooks", ConnectionString: "http://:8080"}, ":8080", "/hooks", orchestrator.DefaultWebhookMaxBodySize, false},
+		{"everything set", orchestrator.InputConfig{Name: "hooks", ConnectionString: "http://0.0.0.0:8080/some/path?secret=s3cr3t&max_body_size=1024"}, "0.0.0.0:8080", "/some/path", 1024, false},
+
+		// Error cases
+		{"missing name", orchestrator.InputConfig{ConnectionString: "http://:8080"}, "", "", 0, true},
+		{"invalid url", orchestrator.InputConfig{Name: "hooks", ConnectionString: "http://%zz"}, "", "", 0, true},
+		{"invalid max_body_size", orchestrator.InputConfig{Name: "hooks", ConnectionString: "http://:8080?max_body_size=lots"}, "", "", 0, true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			i, err := orchestrator.NewWebhookInput(test.ic)
+			if err == nil && test.expectError {
+				t.Fatal("expected error, received none")
+			} else if err != nil && !test.expectError {
+				t.Fatalf("unexpected error %#v", err)
+			}
+
+			if test.expectError {
+				return
+			}
+
+			w := i.(*orchestrator.WebhookInput)
+			if w.Addr != test.expectAddr {
+				t.Errorf("expected addr %q, received %q", test.expectAddr, w.Addr)
+			}
+
+			if w.Path != test.expectPath {
+				t.Errorf("expected path %q, received %q", test.expectPath, w.Path)
+			}
+
+			if w.MaxBodySize != test.expectSize {
+				t.Errorf("expected max body size %d, received %d", test.expectSize, w.MaxBodySize)
+			}
+		})
+	}
+}
+
+func TestWebhookInput_ServeHTTP(t *testing.T) {
+	body := `{"location":"orders","operation":"create","id":"123"}`
+
+	for _, test := range []struct {
+		name   string
+		method string
+		body   string
+		header string
+		read   bool
+		expect int
+	}{
+		{"valid request", http.MethodPost, body, sign("s3cr3t", body), true, http.StatusAccepted},
+		{"backpressured", http.MethodPost, body, sign("s3cr3t", body), false, http.StatusServiceUnavailable},
+		{"wrong method", http.MethodGet, "", "", true, http.StatusMethodNotAllowed},
+		{"missing signature", http.MethodPost, body, "", true, http.StatusUnauthorized},
+		{"invalid signature", http.MethodPost, body, sign("guessed", body), true, http.StatusUnauthorized},
+		{"invalid json", http.MethodPost, "{", sign("s3cr3t", "{"), true, http.StatusBadRequest},
+		{"invalid operation", http.MethodPost, `{"operation":"upsert"}`, sign("s3cr3t", `{"operation":"upsert"}`), true, http.StatusBadRequest},
+		{"too large", http.MethodPost, strings.Repeat(" ", 2048) + body, "", true, http.StatusRequestEntityTooLarge},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+
+			i, err := orchestrator.NewWebhookInput(orchestrator.InputConfig{
+				Name:             "hooks",
+				ConnectionString: "http://:0?secret=s3cr3t&max_body_size=1024",
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			w := i.(*orchestrator.WebhookInput)
+			w.Mux = mux
+			w.AcceptTimeout = time.Millisecond * 10
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			c := make(chan orchestrator.Event, 1)
+			if !test.read {
+				c = make(chan orchestrator.Event)
+			}
+
+			go w.Handle(ctx, c)
+
+			// Give Handle time to register
+			time.Sleep(time.Millisecond * 10)
+
+			r := httptest.NewRequest(test.method, "/hooks", strings.NewReader(test.body))
+			r.Header.Set(orchestrator.DefaultWebhookSignatureHeader, test.header)
+
+			rw := httptest.NewRecorder()
+			mux.ServeHTTP(rw, r)
+
+			if test.expect != rw.Code {
+				t.Errorf("expected status %d, received %d", test.expect, rw.Code)
+			}
+
+			if test.expect != http.StatusAccepted {
+				return
+			}
+
+			expect := orchestrator.Event{Location: "orders", Operation: orchestrator.OperationCreate, ID: "123", Trigger: "hooks"}
+			if received := <-c; !reflect.DeepEqual(expect, received) {
+				t.Errorf("expected\n%#v\nreceived\n%#v", expect, received)
+			}
+		})
+	}
+}
+
+func TestWebhookInput_ServeHTTP_NotRunning(t *testing.T) {
+	i, err := orchestrator.NewWebhookInput(orchestrator.InputConfig{Name: "hooks"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rw := httptest.NewRecorder()
+	i.(http.Handler).ServeHTTP(rw, httptest.NewRequest(http.MethodPost, "/hooks", strings.NewReader(`{}`)))
+
+	if rw.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status %d, received %d", http.StatusServiceUnavailable, rw.Code)
+	}
+}
+
+func TestWebhookInput_Handle(t *testing.T) {
+	i, err := orchestrator.NewWebhookInput(orchestrator.InputConfig{Name: "hooks", ConnectionString: "http://127.0.0.1:0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	errs := make(chan error)
+	go func() {
+		errs <- i.Handle(ctx, make(chan orchestrator.Event))
+	}()
+
+	time.Sleep(time.Millisecond * 10)
+	cancel()
+
+	select {
+	case err = <-errs:
+		if err != context.Canceled {
+			t.Errorf("expected context.Canceled, received %#v", err)
+		}
+
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for server to stop")
+	}
+}
+
+func TestWebhookInput_Handle_SharedAddr(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addr := l.Addr().String()
+	l.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errs := make(chan error, 3)
+	events := make(map[string]chan orchestrator.Event)
+
+	for _, name := range []string{"orders", "payments"} {
+		i, err := orchestrator.NewWebhookInput(orchestrator.InputConfig{Name: name, ConnectionString: "http://" + addr})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		events[name] = make(chan orchestrator.Event, 1)
+
+		go func(c chan orchestrator.Event) {
+			errs <- i.Handle(ctx, c)
+		}(events[name])
+	}
+
+	for name, c := range events {
+		var resp *http.Response
+
+		// Give the server a moment to start
+		deadline := time.Now().Add(time.Second)
+		for {
+			resp, err = http.Post("http://"+addr+"/"+name, "application/json", strings.NewReader(`{"id":"1"}`))
+			if err == nil || time.Now().After(deadline) {
+				break
+			}
+
+			time.Sleep(time.Millisecond * 10)
+		}
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusAccepted {
+			t.Errorf("%s: expected status %d, received %d", name, http.StatusAccepted, resp.StatusCode)
+		}
+
+		select {
+		case ev := <-c:
+			if ev.Trigger != name {
+				t.Errorf("expected trigger %q, received %q", name, ev.Trigger)
+			}
+
+		case <-time.After(time.Second):
+			t.Fatalf("%s: timed out waiting for event", name)
+		}
+	}
+
+	// Paths must still be unique
+	duplicate, err := orchestrator.NewWebhookInput(orchestrator.InputConfig{Name: "more-orders", ConnectionString: "http://" + addr + "/orders"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = duplicate.Handle(ctx, make(chan orchestrator.Event))
+	if err == nil {
+		t.Error("expected error, received none")
+	}
+
+	cancel()
+
+	for j := 0; j < 2; j++ {
+		select {
+		case err = <-errs:
+			if err != context.Canceled {
+				t.Errorf("expected context.Canceled, received %#v", err)
+			}
+
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for server to stop")
+		}
+	}
+}
@@ -0,0 +1,368 @@
+package orchestrator
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Defaults used by NewWebhookProcess
+const (
+	DefaultWebhookTimeout     = time.Second * 30
+	DefaultWebhookMaxLogBytes = 4096
+)
+
+func init() {
+	RegisterProcess("webhook", NewWebhookProcess)
+}
+
+// WebhookResponseError is returned by a WebhookProcess when the response
+// it receives has a status code which is not one of its SuccessCodes
+type WebhookResponseError struct {
+	Process    string
+	StatusCode int
+}
+
+// Error returns a descriptive error message
+func (e WebhookResponseError) Error() string {
+	return fmt.Sprintf("webhook process %q received unexpected status %d", e.Process, e.StatusCode)
+}
+
+// Retryable returns true for server errors and rate limiting, which may
+// well succeed when tried again (see RetryPolicy)
+func (e WebhookResponseError) Retryable() bool {
+	return e.StatusCode >= 500 || e.StatusCode == http.StatusTooManyRequests
+}
+
+// StatusRange is an inclusive range of HTTP status codes
+type StatusRange struct {
+	From, To int
+}
+
+// Contains returns true if code is within this StatusRange
+func (r StatusRange) Contains(code int) bool {
+	return code >= r.From && code <= r.To
+}
+
+// WebhookProcess is a Process which sends each Event to a URL over HTTP.
+//
+// By default, the Event is sent as JSON (see Event.JSON), although a Body
+// template can be given instead, which is executed with the Event as data, and
+// has access to a json function, such as:
+//
+//	{"text": "{{ .Location }} changed", "event": {{ json . }}}
+//
+// Responses with a status code in SuccessCodes give a ProcessSuccess, and
+// anything else gives a ProcessFail, along with a WebhookResponseError. The
+// response body, truncated to MaxLogBytes, is added to the ProcessStatus' Logs.
+//
+// Only the scheme and host of URL make it into Logs and errors, since webhook
+// URLs often contain secrets
+type WebhookProcess struct {
+	name string
+
+	// URL is the URL to send Events to
+	URL string
+
+	// Method is the HTTP method to use
+	Method string
+
+	// Header contains headers to send with each request
+	Header http.Header
+
+	// Body, when set, is used to build request bodies in place of the
+	// Event's JSON representation
+	Body *template.Template
+
+	// SuccessCodes contains the response status codes which denote success
+	SuccessCodes []StatusRange
+
+	// MaxLogBytes is the amount of the response body to log
+	MaxLogBytes int
+
+	// Client is used to make requests
+	Client *http.Client
+}
+
+// NewWebhookProcess accepts a ProcessConfig and returns a WebhookProcess,
+// which implements the orchestrator.Process interface.
+//
+// The ExecutionContext of pc supports the following keys:
+//
+//	url:                  the URL to send events to (required)
+//	method:               the HTTP method to use, defaulting to POST
+//	body:                 a text/template to build request bodies from
+//	timeout:              a request timeout, such as 10s, defaulting to 30s
+//	success_codes:        comma separated status codes and ranges which denote
+//	                      success, such as 200,202-204, defaulting to 200-299
+//	max_log_bytes:        the amount of the response body to log, defaulting to 4096
+//	insecure_skip_verify: set to "true" to skip TLS certificate verification
+//	ca_file:              a PEM encoded CA certificate bundle to verify servers with
+//	cert_file, key_file:  a PEM encoded client certificate and key
+//	header.*:             request headers, where header.X-Foo=bar sets X-Foo: bar
+func NewWebhookProcess(pc ProcessConfig) (p Process, err error) {
+	if pc.ID() == "" {
+		return nil, errors.New("webhook process: name must be set")
+	}
+
+	ec := pc.ExecutionContext
+
+	w := WebhookProcess{
+		name:         pc.ID(),
+		URL:          ec["url"],
+		Method:       ec["method"],
+		Header:       make(http.Header),
+		SuccessCodes: []StatusRange{{From: 200, To: 299}},
+		MaxLogBytes:  DefaultWebhookMaxLogBytes,
+	}
+
+	if w.URL == "" {
+		return nil, errors.New("webhook process: url must be set")
+	}
+
+	if w.Method == "" {
+		w.Method = http.MethodPost
+	}
+
+	for k, v := range ec {
+		if name, ok := strings.CutPrefix(k, "header."); ok {
+			w.Header.Set(name, v)
+		}
+	}
+
+	if ec["body"] != "" {
+		w.Body, err = template.New(pc.ID()).Funcs(webhookTemplateFuncs).Parse(ec["body"])
+		if err != nil {
+			return nil, fmt.Errorf("webhook process: invalid body: %w", err)
+		}
+	}
+
+	if ec["success_codes"] != "" {
+		w.SuccessCodes, err = parseStatusRanges(ec["success_codes"])
+		if err != nil {
+			return nil, fmt.Errorf("webhook process: invalid success_codes: %w", err)
+		}
+	}
+
+	if ec["max_log_bytes"] != "" {
+		w.MaxLogBytes, err = strconv.Atoi(ec["max_log_bytes"])
+		if err != nil {
+			return nil, fmt.Errorf("webhook process: invalid max_log_bytes: %w", err)
+		}
+	}
+
+	timeout := DefaultWebhookTimeout
+	if ec["timeout"] != "" {
+		timeout, err = time.ParseDuration(ec["timeout"])
+		if err != nil {
+			return nil, fmt.Errorf("webhook process: invalid timeout: %w", err)
+		}
+	}
+
+	tlsConfig, err := webhookTLSConfig(ec)
+	if err != nil {
+		return nil, fmt.Errorf("webhook process: %w", err)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	w.Client = &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+	}
+
+	return w, nil
+}
+
+var webhookTemplateFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+
+		return string(b), err
+	},
+}
+
+func webhookTLSConfig(ec map[string]string) (c *tls.Config, err error) {
+	c = &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: ec["insecure_skip_verify"] == "true",
+	}
+
+	if ec["ca_file"] != "" {
+		var pem []byte
+
+		pem, err = os.ReadFile(ec["ca_file"])
+		if err != nil {
+			return
+		}
+
+		c.RootCAs = x509.NewCertPool()
+		if !c.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", ec["ca_file"])
+		}
+	}
+
+	if ec["cert_file"] != "" || ec["key_file"] != "" {
+		var cert tls.Certificate
+
+		cert, err = tls.LoadX509KeyPair(ec["cert_file"], ec["key_file"])
+		if err != nil {
+			return
+		}
+
+		c.Certificates = []tls.Certificate{cert}
+	}
+
+	return
+}
+
+// parseStatusRanges parses strings such as 200,202-204 into StatusRanges
+func parseStatusRanges(s string) (ranges []StatusRange, err error) {
+	ranges = make([]StatusRange, 0)
+
+	for _, part := range strings.Split(s, ",") {
+		var r StatusRange
+
+		from, to, isRange := strings.Cut(strings.TrimSpace(part), "-")
+
+		r.From, err = strconv.Atoi(from)
+		if err != nil {
+			return
+		}
+
+		r.To = r.From
+		if isRange {
+			r.To, err = strconv.Atoi(to)
+			if err != nil {
+				return
+			}
+		}
+
+		ranges = append(ranges, r)
+	}
+
+	return
+}
+
+// ID returns the ID for this Process
+func (w WebhookProcess) ID() string {
+	return w.name
+}
+
+// Run sends ev to URL
+func (w WebhookProcess) Run(ctx context.Context, ev Event) (ps ProcessStatus, err error) {
+	ps.Name = w.name
+	ps.Status = ProcessUnstarted
+
+	body, err := w.body(ev)
+	if err != nil {
+		return
+	}
+
+	req, err := http.NewRequestWithContext(ctx, w.Method, w.URL, bytes.NewReader(body))
+	if err != nil {
+		var ue *url.Error
+		if errors.As(err, &ue) {
+			ue.URL = "[redacted]"
+		}
+
+		return
+	}
+
+	req.Header = w.Header.Clone()
+	if req.Header == nil {
+		req.Header = make(http.Header)
+	}
+
+	// Allow whatever receives this request to continue the trace
+	for _, k := range []string{MetadataTraceParent, MetadataTraceState} {
+		if v, ok := ev.Metadata[k]; ok && req.Header.Get(k) == "" {
+			req.Header.Set(k, v)
+		}
+	}
+
+	if req.Header.Get("Content-Type") == "" && w.Body == nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	client := w.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		ps.Status = ProcessFail
+
+		// Errors from Do contain the full URL, which ends up in logs,
+		// Runs, and DeadLetters
+		var ue *url.Error
+		if errors.As(err, &ue) {
+			ue.URL = redactURL(req.URL)
+		}
+
+		return
+	}
+
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, int64(w.MaxLogBytes)))
+	if err != nil {
+		ps.Status = ProcessFail
+
+		return
+	}
+
+	ps.Logs = []string{fmt.Sprintf("%s %s -> %s", w.Method, redactURL(req.URL), resp.Status)}
+	if len(respBody) > 0 {
+		ps.Logs = append(ps.Logs, string(respBody))
+	}
+
+	for _, r := range w.SuccessCodes {
+		if r.Contains(resp.StatusCode) {
+			ps.Status = ProcessSuccess
+
+			return
+		}
+	}
+
+	ps.Status = ProcessFail
+	err = WebhookResponseError{
+		Process:    w.name,
+		StatusCode: resp.StatusCode,
+	}
+
+	return
+}
+
+func (w WebhookProcess) body(ev Event) ([]byte, error) {
+	if w.Body == nil {
+		j, err := ev.JSON()
+
+		return []byte(j), err
+	}
+
+	buf := new(bytes.Buffer)
+	err := w.Body.Execute(buf, ev)
+
+	return buf.Bytes(), err
+}
+
+// redactURL returns only the scheme and host of u, since webhook URLs
+// often contain secrets, such as tokens in their path or query
+func redactURL(u *url.URL) string {
+	return u.Scheme + "://" + u.Host
+}
@@ -0,0 +1,242 @@
+package orchestrator_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dapper-data/dapper-orchestrator"
+)
+
+// webhookRequest holds the details of a request received in tests
+type webhookRequest struct {
+	method string
+	header http.Header
+	body   string
+}
+
+func newWebhookServer(t *testing.T, tls bool, status int, response string) (*httptest.Server, chan webhookRequest) {
+	t.Helper()
+
+	requests := make(chan webhookRequest, 1)
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- webhookRequest{method: r.Method, header: r.Header, body: string(body)}
+
+		w.WriteHeader(status)
+		w.Write([]byte(response))
+	})
+
+	var s *httptest.Server
+	if tls {
+		s = httptest.NewTLSServer(h)
+	} else {
+		s = httptest.NewServer(h)
+	}
+
+	t.Cleanup(s.Close)
+
+	return s, requests
+}
+
+func TestNewWebhookProcess_Errors(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		ec   map[string]string
+	}{
+		{"missing url", map[string]string{}},
+		{"invalid body", map[string]string{"url": "http://localhost", "body": "{{ .Nope"}},
+		{"invalid success codes", map[string]string{"url": "http://localhost", "success_codes": "200-ok"}},
+		{"invalid timeout", map[string]string{"url": "http://localhost", "timeout": "soon"}},
+		{"invalid max log bytes", map[string]string{"url": "http://localhost", "max_log_bytes": "lots"}},
+		{"missing ca file", map[string]string{"url": "http://localhost", "ca_file": "/does/not/exist"}},
+		{"missing key file", map[string]string{"url": "http://localhost", "cert_file": "/does/not/exist"}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := orchestrator.NewWebhookProcess(orchestrator.ProcessConfig{Name: "webhook", ExecutionContext: test.ec})
+			if err == nil {
+				t.Error("expected error, received none")
+			}
+		})
+	}
+}
+
+func TestWebhookProcess_Run(t *testing.T) {
+	ev := orchestrator.Event{Location: "orders", Operation: orchestrator.OperationCreate, ID: "123", Trigger: "tests"}
+
+	for _, test := range []struct {
+		name         string
+		tls          bool
+		status       int
+		response     string
+		ec           map[string]string
+		expectMethod string
+		expectBody   string
+		expectHeader map[string]string
+		expectStatus orchestrator.ProcessExitStatus
+		expectLogs   []string
+		expectError  bool
+	}{
+		{"defaults", false, http.StatusOK, "thanks", map[string]string{}, http.MethodPost, `{"location":"orders","operation":"create","id":"123","trigger":"tests"}`, map[string]string{"Content-Type": "application/json"}, orchestrator.ProcessSuccess, []string{"200 OK", "thanks"}, false},
+		{"templated body", false, http.StatusNoContent, "", map[string]string{"method": "PUT", "body": `{"text":"{{ .Location }} changed","event":{{ json . }}}`, "header.Authorization": "Bearer t0k3n"}, http.MethodPut, `{"text":"orders changed","event":{"location":"orders","operation":"create","id":"123","trigger":"tests"}}`, map[string]string{"Authorization": "Bearer t0k3n"}, orchestrator.ProcessSuccess, []string{"204 No Content"}, false},
+		{"truncated response", false, http.StatusAccepted, "a very long response", map[string]string{"max_log_bytes": "6", "success_codes": "200, 202-204"}, http.MethodPost, "", nil, orchestrator.ProcessSuccess, []string{"202 Accepted", "a very"}, false},
+		{"tls", true, http.StatusOK, "", map[string]string{"insecure_skip_verify": "true"}, http.MethodPost, "", nil, orchestrator.ProcessSuccess, []string{"200 OK"}, false},
+
+		// Error cases
+		{"unexpected status", false, http.StatusInternalServerError, "oops", map[string]string{}, http.MethodPost, "", nil, orchestrator.ProcessFail, []string{"500 Internal Server Error", "oops"}, true},
+		{"unexpected success", false, http.StatusOK, "", map[string]string{"success_codes": "202"}, http.MethodPost, "", nil, orchestrator.ProcessFail, []string{"200 OK"}, true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			s, requests := newWebhookServer(t, test.tls, test.status, test.response)
+
+			test.ec["url"] = s.URL
+
+			p, err := orchestrator.NewWebhookProcess(orchestrator.ProcessConfig{Name: "webhook", ExecutionContext: test.ec})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ps, err := p.Run(context.Background(), ev)
+			if err == nil && test.expectError {
+				t.Error("expected error, received none")
+			} else if err != nil && !test.expectError {
+				t.Errorf("unexpected error %#v", err)
+			}
+
+			if test.expectError {
+				var wre orchestrator.WebhookResponseError
+				if !errors.As(err, &wre) || wre.StatusCode != test.status {
+					t.Errorf("expected orchestrator.WebhookResponseError with status %d, received %#v", test.status, err)
+				}
+			}
+
+			if test.expectStatus != ps.Status {
+				t.Errorf("expected status %d, received %d", test.expectStatus, ps.Status)
+			}
+
+			// Strip out the method and url, which change between runs
+			for i, l := range ps.Logs {
+				if strings.HasPrefix(l, test.expectMethod+" "+s.URL+" -> ") {
+					ps.Logs[i] = strings.TrimPrefix(l, test.expectMethod+" "+s.URL+" -> ")
+				}
+			}
+
+			if !reflect.DeepEqual(test.expectLogs, ps.Logs) {
+				t.Errorf("expected logs %q, received %q", test.expectLogs, ps.Logs)
+			}
+
+			r := <-requests
+			if r.method != test.expectMethod {
+				t.Errorf("expected method %q, received %q", test.expectMethod, r.method)
+			}
+
+			if test.expectBody != "" && r.body != test.expectBody {
+				t.Errorf("expected body\n%s\nreceived\n%s", test.expectBody, r.body)
+			}
+
+			for k, v := range test.expectHeader {
+				if r.header.Get(k) != v {
+					t.Errorf("expected header %s: %q, received %q", k, v, r.header.Get(k))
+				}
+			}
+		})
+	}
+}
+
+func TestWebhookProcess_Run_TLSVerification(t *testing.T) {
+	s, _ := newWebhookServer(t, true, http.StatusOK, "")
+
+	p, err := orchestrator.NewWebhookProcess(orchestrator.ProcessConfig{Name: "webhook", ExecutionContext: map[string]string{"url": s.URL}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ps, err := p.Run(context.Background(), orchestrator.Event{})
+	if err == nil {
+		t.Error("expected certificate error, received none")
+	}
+
+	if ps.Status != orchestrator.ProcessFail {
+		t.Errorf("expected status %d, received %d", orchestrator.ProcessFail, ps.Status)
+	}
+}
+
+func TestWebhookProcess_Run_TraceContext(t *testing.T) {
+	s, requests := newWebhookServer(t, false, http.StatusOK, "")
+
+	p, err := orchestrator.NewWebhookProcess(orchestrator.ProcessConfig{Name: "webhook", ExecutionContext: map[string]string{"url": s.URL}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"
+
+	_, err = p.Run(context.Background(), orchestrator.Event{Metadata: map[string]string{orchestrator.MetadataTraceParent: expect}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	received := (<-requests).header.Get("traceparent")
+	if expect != received {
+		t.Errorf("expected %q, received %q", expect, received)
+	}
+}
+
+func TestWebhookProcess_Run_RedactsURL(t *testing.T) {
+	s, _ := newWebhookServer(t, false, http.StatusOK, "")
+
+	for _, test := range []struct {
+		name      string
+		url       string
+		expectErr bool
+	}{
+		{"success", s.URL + "/services/s3cr3t?token=s3cr3t", false},
+		{"request error", "http://127.0.0.1:1/services/s3cr3t?token=s3cr3t", true},
+		{"invalid url", "http://127.0.0.1:1/services/s3cr3t\x7f", true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			p, err := orchestrator.NewWebhookProcess(orchestrator.ProcessConfig{Name: "webhook", ExecutionContext: map[string]string{"url": test.url}})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ps, err := p.Run(context.Background(), orchestrator.Event{})
+			if err == nil && test.expectErr {
+				t.Fatal("expected error, received none")
+			} else if err != nil && !test.expectErr {
+				t.Fatalf("unexpected error %#v", err)
+			}
+
+			if err != nil && strings.Contains(err.Error(), "s3cr3t") {
+				t.Errorf("expected error to be redacted, received %q", err.Error())
+			}
+
+			for _, l := range ps.Logs {
+				if strings.Contains(l, "s3cr3t") {
+					t.Errorf("expected logs to be redacted, received %q", l)
+				}
+			}
+
+			if !test.expectErr {
+				expect := "POST " + s.URL + " -> 200 OK"
+				if ps.Logs[0] != expect {
+					t.Errorf("expected %q, received %q", expect, ps.Logs[0])
+				}
+			}
+		})
+	}
+}
+
+func TestWebhookResponseError_Error(t *testing.T) {
+	expect := `webhook process "webhook" received unexpected status 500`
+	err := orchestrator.WebhookResponseError{Process: "webhook", StatusCode: 500}
+
+	if expect != err.Error() {
+		t.Errorf("expected\n%s\nreceived\n%s", expect, err.Error())
+	}
+}